@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -8,23 +10,94 @@ import (
 	"github.com/oblassov/game-score-server/internal/app/cli"
 	"github.com/oblassov/game-score-server/internal/engine"
 	"github.com/oblassov/game-score-server/internal/game/texasholdem"
-	"github.com/oblassov/game-score-server/internal/storage/filesystem"
+	"github.com/oblassov/game-score-server/internal/storage"
 )
 
-const dbFileName = "./game.db.json"
+const (
+	leaguesDirName = "./leagues"
+	blindsDirName  = "./blinds"
+)
 
 func main() {
-	store, closeStore, err := filesystem.PlayerStoreFromFile(dbFileName)
+	storeKind := flag.String("store", storage.KindFile, "storage backend: file, eventlog or sqlite")
+	dbPath := flag.String("db", "", "path to the database (default ./game.db.json, ./game.log or ./game.db, by store)")
+	blindsDir := flag.String("blinds-dir", blindsDirName, "directory of YAML and JSON blind structure presets")
+	blindsName := flag.String("blinds", engine.StandardBlindsName, "blind structure preset to play with")
+	leaguesDir := flag.String("leagues", leaguesDirName, "directory keeping a database for every named league")
+	league := flag.String("league", "", "named league to manage the API keys of, instead of the server's")
+	flag.Parse()
+
+	if *dbPath == "" {
+		*dbPath = storage.DefaultPath(*storeKind)
+	}
+
+	if flag.Arg(0) == "keys" {
+		if err := manageKeys(*storeKind, *dbPath, *leaguesDir, *league, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "users" {
+		users := storage.OpenUsers(storage.UsersPath(*storeKind, *dbPath))
+		if err := cli.ManageUsers(context.Background(), users, flag.Args()[1:], os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
+	players := storage.OpenPlayers(storage.PlayersPath(*storeKind, *dbPath))
+
+	store, closeStore, err := storage.Open(*storeKind, *dbPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer closeStore()
 
+	if flag.Arg(0) == "players" {
+		if err := cli.ManagePlayers(context.Background(), players, store, flag.Args()[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	presets, err := engine.LoadBlindPresets(*blindsDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	blinds, err := presets.Get(*blindsName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	fmt.Println("Let's play poker")
 	fmt.Println("Type {Name} wins to record a win")
 	game := texasholdem.NewTexasHoldem(store, engine.BlindAlerterFunc(engine.Alerter))
 
-	cli := cli.NewCLI(os.Stdin, os.Stdout, game)
-	cli.PlayPoker()
+	cli := cli.NewCLI(os.Stdin, os.Stdout, game, cli.WithBlinds(blinds), cli.WithPlayers(players))
+	if err := cli.PlayPoker(context.Background()); err != nil {
+		log.Println(err)
+	}
+}
+
+// manageKeys manages the API keys of the server's league, or of the named
+// league if there is one.
+func manageKeys(storeKind, dbPath, leaguesDir, league string, args []string) error {
+	ctx := context.Background()
+
+	keys := engine.KeyStore(storage.OpenKeys(storage.KeysPath(storeKind, dbPath)))
+	if league != "" {
+		leagues, err := storage.NewLeagues(storeKind, leaguesDir)
+		if err != nil {
+			return err
+		}
+		defer leagues.Close()
+
+		if keys, err = leagues.Keys(ctx, league); err != nil {
+			return err
+		}
+	}
+
+	return cli.ManageKeys(ctx, keys, args, os.Stdout)
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"net/http"
 	"time"
@@ -8,20 +10,64 @@ import (
 	"github.com/oblassov/game-score-server/internal/engine"
 	"github.com/oblassov/game-score-server/internal/game/texasholdem"
 	"github.com/oblassov/game-score-server/internal/server"
-	"github.com/oblassov/game-score-server/internal/storage/filesystem"
+	"github.com/oblassov/game-score-server/internal/storage"
 )
 
-const dbFileName = "./game.db.json"
+const (
+	leaguesDirName = "./leagues"
+	blindsDirName  = "./blinds"
+)
+
+// seasonCheckInterval is how often the server checks whether a quarter ended
+// and its season should be closed.
+const seasonCheckInterval = time.Hour
 
 func main() {
-	store, closeStore, err := filesystem.PlayerStoreFromFile(dbFileName)
+	storeKind := flag.String("store", storage.KindFile, "storage backend: file, eventlog or sqlite")
+	dbPath := flag.String("db", "", "path to the database (default ./game.db.json, ./game.log or ./game.db, by store)")
+	leaguesDir := flag.String("leagues", leaguesDirName, "directory keeping a database for every named league")
+	blindsDir := flag.String("blinds-dir", blindsDirName, "directory of YAML and JSON blind structure presets")
+	privateReads := flag.Bool("private-reads", false, "take a viewer to read the leagues too, not only a scorekeeper or admin to change them")
+	flag.Parse()
+
+	if *dbPath == "" {
+		*dbPath = storage.DefaultPath(*storeKind)
+	}
+
+	presets, err := engine.LoadBlindPresets(*blindsDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, closeStore, err := storage.Open(*storeKind, *dbPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer closeStore()
 
-	game := texasholdem.NewTexasHoldem(store, engine.BlindAlerterFunc(engine.Alerter))
-	playerServer, err := server.NewPlayerServer(store, game)
+	leagues, err := storage.NewLeagues(*storeKind, *leaguesDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer leagues.Close()
+
+	go closeSeasons(store, leagues)
+
+	newGame := func() engine.Game {
+		return texasholdem.NewTexasHoldem(store, engine.BlindAlerterFunc(engine.Alerter))
+	}
+	opts := []server.Option{
+		server.WithLeagues(leagues),
+		server.WithBlindPresets(presets),
+		server.WithAPIKeys(storage.OpenKeys(storage.KeysPath(*storeKind, *dbPath))),
+		server.WithUsers(storage.OpenUsers(storage.UsersPath(*storeKind, *dbPath))),
+		server.WithPlayers(storage.OpenPlayers(storage.PlayersPath(*storeKind, *dbPath))),
+	}
+	if *privateReads {
+		opts = append(opts, server.WithPrivateReads())
+	}
+
+	playerServer, err := server.NewPlayerServer(store, newGame, opts...)
 
 	if err != nil {
 		log.Printf("problem creating player server %v", err)
@@ -42,3 +88,41 @@ func main() {
 		return
 	}
 }
+
+// closeSeasons closes the running season of the league and of every named
+// league at the end of every quarter.
+func closeSeasons(store engine.PlayerStore, leagues engine.Leagues) {
+	ticker := time.NewTicker(seasonCheckInterval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		ctx := context.Background()
+		now := time.Now().UTC()
+
+		closeSeason(ctx, "the league", store, now)
+
+		ids, err := leagues.ListLeagues(ctx)
+		if err != nil {
+			log.Printf("couldn't list the leagues: %v", err)
+		}
+
+		for _, id := range ids {
+			store, err := leagues.Store(ctx, id)
+			if err != nil {
+				log.Printf("couldn't open league %s: %v", id, err)
+				continue
+			}
+			closeSeason(ctx, "league "+id, store, now)
+		}
+	}
+}
+
+func closeSeason(ctx context.Context, name string, store engine.PlayerStore, now time.Time) {
+	closed, err := engine.CloseQuarter(ctx, store, now)
+	if err != nil {
+		log.Printf("couldn't close the season of %s: %v", name, err)
+	}
+	if closed {
+		log.Printf("closed the season, %s starts over", name)
+	}
+}
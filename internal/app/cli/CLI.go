@@ -2,59 +2,155 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/oblassov/game-score-server/internal/engine"
 )
 
-const PlayerPrompt = "Please enter the number of players: "
-const BadPlayerInputErrMsg = "bad value received for number of players, please try again with a number"
+const PlayerPrompt = "Please enter the number of players, or their names separated by commas: "
+const BadPlayerInputErrMsg = "bad value received for number of players, please try again with a number or at least 2 names"
 const BadWinnerInputErrMsg = "bad value received for winner, please try using '%NAME% wins'"
+const NotParticipantPrompt = "%s didn't play, record the win anyway? (y/n): "
+const WinNotRecordedMsg = "the win wasn't recorded"
 
 type CLI struct {
-	in   *bufio.Scanner
-	out  io.Writer
-	game engine.Game
+	in      *bufio.Scanner
+	out     io.Writer
+	game    engine.Game
+	blinds  engine.BlindStructure
+	players engine.PlayerRegistry
 }
 
-func NewCLI(in io.Reader, out io.Writer, game engine.Game) *CLI {
-	return &CLI{
-		in:   bufio.NewScanner(in),
-		out:  out,
-		game: game,
+// Option configures the optional parts of a CLI.
+type Option func(*CLI)
+
+// WithBlinds plays games with blinds instead of the standard structure.
+func WithBlinds(blinds engine.BlindStructure) Option {
+	return func(cli *CLI) {
+		cli.blinds = blinds
+	}
+}
+
+// WithPlayers records winners under the name registry keeps for them.
+func WithPlayers(registry engine.PlayerRegistry) Option {
+	return func(cli *CLI) {
+		cli.players = registry
+	}
+}
+
+func NewCLI(in io.Reader, out io.Writer, game engine.Game, opts ...Option) *CLI {
+	cli := &CLI{
+		in:     bufio.NewScanner(in),
+		out:    out,
+		game:   game,
+		blinds: engine.StandardBlinds(),
+	}
+
+	for _, opt := range opts {
+		opt(cli)
 	}
+
+	return cli
 }
 
-func (cli *CLI) PlayPoker() {
+// PlayPoker runs a single game, reading the number or names of the players
+// and then the winner. Bad input is reported to the user and stops the game,
+// as does a winner who didn't play unless the user confirms them, while
+// failing to record the result is returned.
+func (cli *CLI) PlayPoker(ctx context.Context) error {
 	if _, err := fmt.Fprint(cli.out, PlayerPrompt); err != nil {
 		log.Println("couldn't print the player number prompt: ", err)
 	}
-	numberOfPlayers, err := strconv.Atoi(cli.readLine())
+	numberOfPlayers, participants, err := cli.readPlayers(ctx)
 	if err != nil {
 		if _, err = fmt.Fprint(cli.out, BadPlayerInputErrMsg); err != nil {
 			log.Println("couldn't print the bad number of players prompt: ", err)
 		}
-		return
+		return nil
 	}
 
-	cli.game.Start(numberOfPlayers, cli.out)
+	cli.game.Start(numberOfPlayers, participants, cli.blinds, cli.out)
 
 	winnerInput := cli.readLine()
 	winner, err := extractWinner(winnerInput)
+	if err == nil {
+		winner, err = cli.register(ctx, winner)
+	}
 
 	if err != nil {
+		cli.game.Stop()
 		if _, err := fmt.Fprint(cli.out, BadWinnerInputErrMsg); err != nil {
 			log.Println("couldn't print the bad winner prompt: ", err)
 		}
-		return
+		return nil
+	}
+
+	err = cli.game.Finish(ctx, winner, false)
+	if !errors.Is(err, engine.ErrNotParticipant) {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(cli.out, NotParticipantPrompt, winner); err != nil {
+		log.Println("couldn't print the winner confirmation prompt: ", err)
+	}
+	if !strings.EqualFold(strings.TrimSpace(cli.readLine()), "y") {
+		cli.game.Stop()
+		if _, err := fmt.Fprint(cli.out, WinNotRecordedMsg); err != nil {
+			log.Println("couldn't print that the win wasn't recorded: ", err)
+		}
+		return nil
+	}
+
+	return cli.game.Finish(ctx, winner, true)
+}
+
+// readPlayers reads the number of players, or their names separated by
+// commas.
+func (cli *CLI) readPlayers(ctx context.Context) (int, []string, error) {
+	line := cli.readLine()
+	if numberOfPlayers, err := strconv.Atoi(strings.TrimSpace(line)); err == nil {
+		return numberOfPlayers, nil, nil
+	}
+
+	names := strings.Split(line, ",")
+	for i, name := range names {
+		var err error
+		if names[i], err = cli.register(ctx, name); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	participants, err := engine.ParseParticipants(names)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(participants) < 2 {
+		return 0, nil, errors.New(BadPlayerInputErrMsg)
 	}
 
-	cli.game.Finish(winner)
+	return len(participants), participants, nil
+}
+
+// register returns the name name is recorded under, registering them if
+// they're new.
+func (cli *CLI) register(ctx context.Context, name string) (string, error) {
+	if cli.players == nil {
+		return name, nil
+	}
+
+	player, err := cli.players.Register(ctx, name, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	return player.Name, nil
 }
 
 func (cli *CLI) readLine() string {
@@ -62,11 +158,13 @@ func (cli *CLI) readLine() string {
 	return cli.in.Text()
 }
 
+// extractWinner returns the name in "NAME wins", however it's spaced or
+// capitalized.
 func extractWinner(userInput string) (string, error) {
-
-	if !strings.Contains(userInput, "wins") {
-		return "", errors.New(BadPlayerInputErrMsg)
+	words := strings.Fields(userInput)
+	if len(words) < 2 || !strings.EqualFold(words[len(words)-1], "wins") {
+		return "", errors.New(BadWinnerInputErrMsg)
 	}
 
-	return strings.Replace(userInput, " wins", "", 1), nil
+	return strings.Join(words[:len(words)-1], " "), nil
 }
@@ -2,12 +2,17 @@ package cli_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/oblassov/game-score-server/internal/app/cli"
+	"github.com/oblassov/game-score-server/internal/engine"
 	"github.com/oblassov/game-score-server/tests"
 )
 
@@ -19,7 +24,7 @@ func TestCLI(t *testing.T) {
 		game := &tests.GameSpy{}
 
 		cliApp := cli.NewCLI(in, stdOut, game)
-		cliApp.PlayPoker()
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
 
 		assertMessagesSentToUser(t, stdOut, cli.PlayerPrompt, cli.BadPlayerInputErrMsg)
 		assertGameNotStarted(t, game)
@@ -31,7 +36,7 @@ func TestCLI(t *testing.T) {
 		game := &tests.GameSpy{}
 
 		cliApp := cli.NewCLI(in, stdOut, game)
-		cliApp.PlayPoker()
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
 
 		assertMessagesSentToUser(t, stdOut, cli.PlayerPrompt)
 		assertGameStartedWith(t, game, 3)
@@ -44,23 +49,132 @@ func TestCLI(t *testing.T) {
 		game := &tests.GameSpy{}
 
 		cliApp := cli.NewCLI(in, stdOut, game)
-		cliApp.PlayPoker()
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
 
 		assertMessagesSentToUser(t, stdOut, cli.PlayerPrompt)
 		assertGameStartedWith(t, game, 8)
 		assertFinishCalledWith(t, game, "Cleo")
 	})
 
+	t.Run("it returns an error when the winner couldn't be recorded", func(t *testing.T) {
+		in := userSends("3", "Chris wins")
+		stdOut := &bytes.Buffer{}
+		game := &tests.GameSpy{FinishErr: errors.New("disk full")}
+
+		cliApp := cli.NewCLI(in, stdOut, game)
+
+		if err := cliApp.PlayPoker(context.Background()); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+	})
+
+	t.Run("it starts the game with the standard blinds by default", func(t *testing.T) {
+		game := &tests.GameSpy{}
+
+		cliApp := cli.NewCLI(userSends("3", "Chris wins"), &bytes.Buffer{}, game)
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
+
+		assertGameStartedWithBlinds(t, game, engine.StandardBlindsName)
+	})
+
+	t.Run("it starts the game with the blinds it was given", func(t *testing.T) {
+		game := &tests.GameSpy{}
+		turbo := engine.BlindStructure{
+			Name:          "turbo",
+			LevelDuration: engine.Duration(3 * time.Minute),
+			Levels:        []engine.BlindLevel{{SmallBlind: 25, BigBlind: 50}},
+		}
+
+		cliApp := cli.NewCLI(userSends("3", "Chris wins"), &bytes.Buffer{}, game, cli.WithBlinds(turbo))
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
+
+		assertGameStartedWithBlinds(t, game, "turbo")
+	})
+
+	t.Run("it finishes the game however the winner is spaced or capitalized", func(t *testing.T) {
+		game := &tests.GameSpy{}
+
+		cliApp := cli.NewCLI(userSends("3", "  Cleo   Patra  WINS "), &bytes.Buffer{}, game)
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
+
+		assertFinishCalledWith(t, game, "Cleo Patra")
+	})
+
+	t.Run("it finishes the game with the registered name of the winner", func(t *testing.T) {
+		game := &tests.GameSpy{}
+		players := &tests.StubPlayerRegistry{Players: engine.Registry{{ID: "cleo", Name: "Cleo", Aliases: []string{"Cleopatra"}}}}
+
+		cliApp := cli.NewCLI(userSends("3", "cleopatra wins"), &bytes.Buffer{}, game, cli.WithPlayers(players))
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
+
+		assertFinishCalledWith(t, game, "Cleo")
+	})
+
+	t.Run("it starts the game with the players named", func(t *testing.T) {
+		game := &tests.GameSpy{}
+
+		cliApp := cli.NewCLI(userSends("Chris, Cleo ,Ruth", "ruth wins"), &bytes.Buffer{}, game)
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
+
+		assertGameStartedWith(t, game, 3)
+		if !slices.Equal(game.StartedWithNames(), []string{"Chris", "Cleo", "Ruth"}) {
+			t.Errorf("got players %q, want Chris, Cleo and Ruth", game.StartedWithNames())
+		}
+		assertFinishCalledWith(t, game, "ruth")
+	})
+
+	t.Run("it refuses games of one player or the same player twice", func(t *testing.T) {
+		for _, players := range []string{"Chris", "Chris, chris"} {
+			stdOut := &bytes.Buffer{}
+			game := &tests.GameSpy{}
+
+			cliApp := cli.NewCLI(userSends(players), stdOut, game)
+			tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
+
+			assertMessagesSentToUser(t, stdOut, cli.PlayerPrompt, cli.BadPlayerInputErrMsg)
+			assertGameNotStarted(t, game)
+		}
+	})
+
+	t.Run("it records a winner who didn't play once confirmed", func(t *testing.T) {
+		stdOut := &bytes.Buffer{}
+		game := &tests.GameSpy{}
+
+		cliApp := cli.NewCLI(userSends("Chris, Cleo", "Floyd wins", "y"), stdOut, game)
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
+
+		assertMessagesSentToUser(t, stdOut, cli.PlayerPrompt, fmt.Sprintf(cli.NotParticipantPrompt, "Floyd"))
+		if game.FinishedWith() != "Floyd" || !game.Confirmed() {
+			t.Errorf("got %q confirmed %v, want Floyd confirmed", game.FinishedWith(), game.Confirmed())
+		}
+	})
+
+	t.Run("it stops the game when a winner who didn't play isn't confirmed", func(t *testing.T) {
+		stdOut := &bytes.Buffer{}
+		game := &tests.GameSpy{}
+
+		cliApp := cli.NewCLI(userSends("Chris, Cleo", "Floyd wins", "n"), stdOut, game)
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
+
+		assertMessagesSentToUser(t, stdOut, cli.PlayerPrompt, fmt.Sprintf(cli.NotParticipantPrompt, "Floyd"), cli.WinNotRecordedMsg)
+		if game.Confirmed() || !game.StopCalled() {
+			t.Error("expected the game to be stopped without recording the win")
+		}
+	})
+
 	t.Run("it prints an error when a winner is declared incorrectly", func(t *testing.T) {
 		in := userSends("7", "Cleo kills")
 		stdOut := &bytes.Buffer{}
 		game := &tests.GameSpy{}
 
 		cliApp := cli.NewCLI(in, stdOut, game)
-		cliApp.PlayPoker()
+		tests.AssertNoError(t, cliApp.PlayPoker(context.Background()))
 
 		assertGameNotFinished(t, game)
 		assertMessagesSentToUser(t, stdOut, cli.PlayerPrompt, cli.BadWinnerInputErrMsg)
+		if !game.StopCalled() {
+			t.Error("expected the game to be stopped")
+		}
 	})
 
 }
@@ -69,11 +183,11 @@ func assertFinishCalledWith(t testing.TB, game *tests.GameSpy, winner string) {
 	t.Helper()
 
 	passed := retryUntil(500*time.Millisecond, func() bool {
-		return game.FinishedWith == winner
+		return game.FinishedWith() == winner
 	})
 
 	if !passed {
-		t.Errorf("expected Finish called with %q, but got %q", winner, game.FinishedWith)
+		t.Errorf("expected Finish called with %q, but got %q", winner, game.FinishedWith())
 	}
 
 }
@@ -93,7 +207,7 @@ func retryUntil(d time.Duration, f func() bool) bool {
 func assertGameNotStarted(t testing.TB, game *tests.GameSpy) {
 	t.Helper()
 
-	if game.StartCalled {
+	if game.StartCalled() {
 		t.Errorf("game should not have started")
 	}
 
@@ -102,7 +216,7 @@ func assertGameNotStarted(t testing.TB, game *tests.GameSpy) {
 func assertGameNotFinished(t testing.TB, game *tests.GameSpy) {
 	t.Helper()
 
-	if game.FinishCalled {
+	if game.FinishCalled() {
 		t.Errorf("game should not have finished")
 	}
 }
@@ -110,12 +224,20 @@ func assertGameNotFinished(t testing.TB, game *tests.GameSpy) {
 func assertGameStartedWith(t testing.TB, game *tests.GameSpy, numberOfPlayers int) {
 	t.Helper()
 
-	if game.StartedWith != numberOfPlayers {
-		t.Errorf("wanted Start called with %d, but go %d", numberOfPlayers, game.StartedWith)
+	if game.StartedWith() != numberOfPlayers {
+		t.Errorf("wanted Start called with %d, but go %d", numberOfPlayers, game.StartedWith())
 	}
 
 }
 
+func assertGameStartedWithBlinds(t testing.TB, game *tests.GameSpy, name string) {
+	t.Helper()
+
+	if game.StartedWithBlinds().Name != name {
+		t.Errorf("wanted Start called with the %q blinds, but got %q", name, game.StartedWithBlinds().Name)
+	}
+}
+
 func assertMessagesSentToUser(t testing.TB, stdOut *bytes.Buffer, messages ...string) {
 	t.Helper()
 
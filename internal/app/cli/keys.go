@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+const KeysUsage = "usage: keys add NAME [viewer|scorekeeper|admin] | keys list | keys revoke ID"
+
+var ErrKeysUsage = errors.New(KeysUsage)
+
+// ManageKeys adds, lists or revokes the API keys in keys, as told by args.
+// The secret of a new key is printed to out, and only then.
+func ManageKeys(ctx context.Context, keys engine.KeyStore, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return ErrKeysUsage
+	}
+
+	switch {
+	case args[0] == "add" && (len(args) == 2 || len(args) == 3):
+		role := engine.RoleScorekeeper
+		if len(args) == 3 {
+			var err error
+			if role, err = engine.ParseRole(args[2]); err != nil {
+				return err
+			}
+		}
+
+		key, secret, err := engine.NewAPIKey(args[1], role, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		if err := keys.AddKey(ctx, key); err != nil {
+			return fmt.Errorf("couldn't add the key: %w", err)
+		}
+
+		_, err = fmt.Fprintf(out, "added %s key %s for %s, keep it safe, it won't be shown again:\n%s\n", key.Role, key.ID, key.Name, secret)
+		return err
+
+	case args[0] == "list" && len(args) == 1:
+		all, err := keys.ListKeys(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't list the keys: %w", err)
+		}
+
+		for _, key := range all {
+			if _, err := fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Allowed(), key.CreatedAt.Format(time.DateTime)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case args[0] == "revoke" && len(args) == 2:
+		if err := keys.RevokeKey(ctx, args[1]); err != nil {
+			return fmt.Errorf("couldn't revoke the key: %w", err)
+		}
+
+		_, err := fmt.Fprintf(out, "revoked key %s\n", args[1])
+		return err
+
+	default:
+		return ErrKeysUsage
+	}
+}
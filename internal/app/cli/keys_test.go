@@ -0,0 +1,61 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oblassov/game-score-server/internal/app/cli"
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestManageKeys(t *testing.T) {
+	ctx := context.Background()
+	keys := &tests.StubKeyStore{}
+
+	t.Run("it adds a key, showing its secret", func(t *testing.T) {
+		out := &bytes.Buffer{}
+
+		tests.AssertNoError(t, cli.ManageKeys(ctx, keys, []string{"add", "scoreboard"}, out))
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(keys.Keys) != 1 || len(lines) != 2 {
+			t.Fatalf("got keys %+v printing %q, want one key and its secret", keys.Keys, out)
+		}
+		if _, err := engine.CheckAPIKey(ctx, keys, lines[1]); err != nil {
+			t.Errorf("couldn't use the secret printed: %v", err)
+		}
+	})
+
+	t.Run("it lists the keys without their secrets", func(t *testing.T) {
+		out := &bytes.Buffer{}
+
+		tests.AssertNoError(t, cli.ManageKeys(ctx, keys, []string{"list"}, out))
+
+		if !strings.HasPrefix(out.String(), keys.Keys[0].ID+"\tscoreboard\t") || strings.Contains(out.String(), keys.Keys[0].Hash) {
+			t.Errorf("got %q, want the id and name of the key", out)
+		}
+	})
+
+	t.Run("it revokes keys", func(t *testing.T) {
+		tests.AssertNoError(t, cli.ManageKeys(ctx, keys, []string{"revoke", keys.Keys[0].ID}, &bytes.Buffer{}))
+
+		if len(keys.Keys) != 0 {
+			t.Errorf("got keys %+v, want none", keys.Keys)
+		}
+		if err := cli.ManageKeys(ctx, keys, []string{"revoke", "nope"}, &bytes.Buffer{}); !errors.Is(err, engine.ErrKeyNotFound) {
+			t.Errorf("got error %v, want %v", err, engine.ErrKeyNotFound)
+		}
+	})
+
+	t.Run("it explains how it's used", func(t *testing.T) {
+		for _, args := range [][]string{nil, {"add"}, {"rotate", "x"}} {
+			if err := cli.ManageKeys(ctx, keys, args, &bytes.Buffer{}); !errors.Is(err, cli.ErrKeysUsage) {
+				t.Errorf("got error %v for %v, want %v", err, args, cli.ErrKeysUsage)
+			}
+		}
+	})
+}
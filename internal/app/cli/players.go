@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+const PlayersUsage = "usage: players list | players alias NAME ALIAS | players merge FROM INTO"
+
+var ErrPlayersUsage = errors.New(PlayersUsage)
+
+// ManagePlayers lists the players in registry, gives them aliases or merges
+// them, along with their matches and wins in store, as told by args.
+func ManagePlayers(ctx context.Context, registry engine.PlayerRegistry, store engine.PlayerStore, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return ErrPlayersUsage
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		all, err := registry.ListPlayers(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't list the players: %w", err)
+		}
+
+		for _, player := range all {
+			if _, err := fmt.Fprintf(out, "%s\t%s\t%s\n", player.ID, player.Name, strings.Join(player.Aliases, ", ")); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case args[0] == "alias" && len(args) == 3:
+		player, err := registry.AddAlias(ctx, args[1], args[2])
+		if err != nil {
+			return fmt.Errorf("couldn't add the alias: %w", err)
+		}
+
+		_, err = fmt.Fprintf(out, "%s is now also %s\n", player.Name, engine.NormalizeName(args[2]))
+		return err
+
+	case args[0] == "merge" && len(args) == 3:
+		player, err := engine.MergePlayers(ctx, registry, store, args[1], args[2], "cli", time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("couldn't merge the players: %w", err)
+		}
+
+		_, err = fmt.Fprintf(out, "merged %s into %s\n", engine.NormalizeName(args[1]), player.Name)
+		return err
+
+	default:
+		return ErrPlayersUsage
+	}
+}
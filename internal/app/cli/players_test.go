@@ -0,0 +1,57 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oblassov/game-score-server/internal/app/cli"
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestManagePlayers(t *testing.T) {
+	ctx := context.Background()
+	players := &tests.StubPlayerRegistry{Players: engine.Registry{{ID: "cleo", Name: "Cleo"}, {ID: "chris", Name: "Chris"}}}
+	store := &tests.StubPlayerStore{
+		Scores: map[string]int{"Cleo": 2, "Chris": 1},
+		League: engine.League{{Name: "Cleo", Wins: 2}, {Name: "Chris", Wins: 1}},
+	}
+
+	t.Run("it gives players aliases", func(t *testing.T) {
+		tests.AssertNoError(t, cli.ManagePlayers(ctx, players, store, []string{"alias", "cleo", "Cleopatra"}, &bytes.Buffer{}))
+
+		if player := players.Players.Find("cleopatra"); player == nil || player.Name != "Cleo" {
+			t.Errorf("got player %+v, want Cleo", player)
+		}
+	})
+
+	t.Run("it merges players along with their wins", func(t *testing.T) {
+		tests.AssertNoError(t, cli.ManagePlayers(ctx, players, store, []string{"merge", "Chris", "Cleo"}, &bytes.Buffer{}))
+
+		tests.AssertPlayerScore(t, store, "Cleo", 3)
+		if len(store.Audit) != 1 || store.Audit[0].By != "cli" {
+			t.Errorf("got audit log %+v, want the merge by cli", store.Audit)
+		}
+	})
+
+	t.Run("it lists the players with their aliases", func(t *testing.T) {
+		out := &bytes.Buffer{}
+
+		tests.AssertNoError(t, cli.ManagePlayers(ctx, players, store, []string{"list"}, out))
+
+		if got, want := strings.TrimSpace(out.String()), "cleo\tCleo\tCleopatra, Chris"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("it explains how it's used", func(t *testing.T) {
+		for _, args := range [][]string{nil, {"alias", "Cleo"}, {"rename", "Cleo", "Chris"}} {
+			if err := cli.ManagePlayers(ctx, players, store, args, &bytes.Buffer{}); !errors.Is(err, cli.ErrPlayersUsage) {
+				t.Errorf("got error %v for %v, want %v", err, args, cli.ErrPlayersUsage)
+			}
+		}
+	})
+}
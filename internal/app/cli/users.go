@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+const UsersUsage = "usage: users add NAME viewer|scorekeeper|admin | users list | users remove NAME"
+
+const PasswordPrompt = "Please enter the password: "
+
+var ErrUsersUsage = errors.New(UsersUsage)
+
+// ManageUsers adds, lists or removes the users in users, as told by args.
+// The password of a new user is read from in.
+func ManageUsers(ctx context.Context, users engine.UserStore, args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		return ErrUsersUsage
+	}
+
+	switch {
+	case args[0] == "add" && len(args) == 3:
+		role, err := engine.ParseRole(args[2])
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprint(out, PasswordPrompt); err != nil {
+			return err
+		}
+		password := bufio.NewScanner(in)
+		password.Scan()
+
+		user, err := engine.NewUser(args[1], role, password.Text(), time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		if err := users.AddUser(ctx, user); err != nil {
+			return fmt.Errorf("couldn't add the user: %w", err)
+		}
+
+		_, err = fmt.Fprintf(out, "\nadded %s %s\n", user.Role, user.Name)
+		return err
+
+	case args[0] == "list" && len(args) == 1:
+		all, err := users.ListUsers(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't list the users: %w", err)
+		}
+
+		for _, user := range all {
+			if _, err := fmt.Fprintf(out, "%s\t%s\t%s\n", user.Name, user.Role, user.CreatedAt.Format(time.DateTime)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case args[0] == "remove" && len(args) == 2:
+		if err := users.RemoveUser(ctx, args[1]); err != nil {
+			return fmt.Errorf("couldn't remove the user: %w", err)
+		}
+
+		_, err := fmt.Fprintf(out, "removed %s\n", args[1])
+		return err
+
+	default:
+		return ErrUsersUsage
+	}
+}
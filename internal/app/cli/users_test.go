@@ -0,0 +1,58 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/oblassov/game-score-server/internal/app/cli"
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestManageUsers(t *testing.T) {
+	ctx := context.Background()
+	users := &tests.StubUserStore{}
+
+	t.Run("it adds a user with the password read", func(t *testing.T) {
+		out := &bytes.Buffer{}
+
+		tests.AssertNoError(t, cli.ManageUsers(ctx, users, []string{"add", "Pepper", "scorekeeper"}, userSends("hunter22"), out))
+
+		if !strings.HasPrefix(out.String(), cli.PasswordPrompt) {
+			t.Errorf("got %q, want the password prompt", out)
+		}
+		if _, err := engine.Login(ctx, users, "Pepper", "hunter22"); err != nil {
+			t.Errorf("couldn't log in as the user added: %v", err)
+		}
+	})
+
+	t.Run("it lists the users", func(t *testing.T) {
+		out := &bytes.Buffer{}
+
+		tests.AssertNoError(t, cli.ManageUsers(ctx, users, []string{"list"}, userSends(), out))
+
+		if !strings.HasPrefix(out.String(), "Pepper\tscorekeeper\t") {
+			t.Errorf("got %q, want Pepper the scorekeeper", out)
+		}
+	})
+
+	t.Run("it removes users", func(t *testing.T) {
+		tests.AssertNoError(t, cli.ManageUsers(ctx, users, []string{"remove", "Pepper"}, userSends(), &bytes.Buffer{}))
+
+		if len(users.Users) != 0 {
+			t.Errorf("got users %+v, want none", users.Users)
+		}
+	})
+
+	t.Run("it refuses bad roles and usage", func(t *testing.T) {
+		if err := cli.ManageUsers(ctx, users, []string{"add", "Pepper", "owner"}, userSends("hunter22"), &bytes.Buffer{}); !errors.Is(err, engine.ErrBadRole) {
+			t.Errorf("got error %v, want %v", err, engine.ErrBadRole)
+		}
+		if err := cli.ManageUsers(ctx, users, []string{"add", "Pepper"}, userSends(), &bytes.Buffer{}); !errors.Is(err, cli.ErrUsersUsage) {
+			t.Errorf("got error %v, want %v", err, cli.ErrUsersUsage)
+		}
+	})
+}
@@ -4,23 +4,195 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sync"
 	"time"
 )
 
+// BlindAlert announces level At into a game.
+type BlindAlert struct {
+	At    time.Duration
+	Level BlindLevel
+	// Lasts is how long until the next level, zero for the last one.
+	Lasts time.Duration
+}
+
+func (a BlindAlert) String() string {
+	return fmt.Sprintf("%v at %v", a.Level, a.At)
+}
+
+// BlindWriter is a destination for alerts that wants them as they are rather
+// than as text.
+type BlindWriter interface {
+	WriteBlind(alert BlindAlert) error
+}
+
+func writeAlert(to io.Writer, alert BlindAlert) error {
+	if w, ok := to.(BlindWriter); ok {
+		return w.WriteBlind(alert)
+	}
+
+	_, err := fmt.Fprintln(to, alert.Level)
+	return err
+}
+
 type BlindAlerter interface {
-	ScheduleAlertAt(duration time.Duration, amount int, to io.Writer)
+	ScheduleAlerts(alerts []BlindAlert, to io.Writer) BlindSchedule
 }
 
-type BlindAlerterFunc func(duration time.Duration, amount int, to io.Writer)
+type BlindAlerterFunc func(alerts []BlindAlert, to io.Writer) BlindSchedule
 
-func (a BlindAlerterFunc) ScheduleAlertAt(duration time.Duration, amount int, to io.Writer) {
-	a(duration, amount, to)
+func (a BlindAlerterFunc) ScheduleAlerts(alerts []BlindAlert, to io.Writer) BlindSchedule {
+	return a(alerts, to)
 }
 
-func Alerter(duration time.Duration, amount int, to io.Writer) {
-	time.AfterFunc(duration, func() {
-		if _, err := fmt.Fprintf(to, "Blind is now %d\n", amount); err != nil {
-			log.Println("couldn't print current blind: ", err)
-		}
+// BlindSchedule controls the alerts of a running game. Paused schedules
+// don't count the time until they are resumed, and cancelled ones never
+// alert again.
+type BlindSchedule interface {
+	Cancel()
+	Pause()
+	Resume()
+	// Remaining is how long until the next alert, zero if there are none left.
+	Remaining() time.Duration
+}
+
+// Alerter writes alerts to to as they come due on the system clock.
+func Alerter(alerts []BlindAlert, to io.Writer) BlindSchedule {
+	return startSchedule(SystemClock{}, alerts, to)
+}
+
+// NewAlerter times alerts with clock.
+func NewAlerter(clock Clock) BlindAlerter {
+	return BlindAlerterFunc(func(alerts []BlindAlert, to io.Writer) BlindSchedule {
+		return startSchedule(clock, alerts, to)
 	})
 }
+
+// startSchedule writes alerts to to as they come due. It stops at the first
+// alert it can't write, as nobody is listening anymore.
+func startSchedule(clock Clock, alerts []BlindAlert, to io.Writer) BlindSchedule {
+	s := &blindSchedule{
+		clock:     clock,
+		alerts:    alerts,
+		to:        to,
+		resumedAt: clock.Now(),
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.arm()
+
+	return s
+}
+
+type blindSchedule struct {
+	clock  Clock
+	alerts []BlindAlert
+	to     io.Writer
+
+	lock sync.Mutex
+	next int
+	// elapsed is the time the schedule ran for until it was last resumed.
+	elapsed   time.Duration
+	resumedAt time.Time
+	paused    bool
+	cancelled bool
+	timer     Timer
+	// generation tells the timer armed last from the ones it replaced, which
+	// may fire even though they were stopped.
+	generation int
+}
+
+func (s *blindSchedule) Cancel() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.cancelled = true
+	s.disarm()
+}
+
+func (s *blindSchedule) Pause() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.paused || s.cancelled {
+		return
+	}
+
+	s.elapsed = s.running()
+	s.paused = true
+	s.disarm()
+}
+
+func (s *blindSchedule) Resume() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.paused || s.cancelled {
+		return
+	}
+
+	s.paused = false
+	s.resumedAt = s.clock.Now()
+	s.arm()
+}
+
+func (s *blindSchedule) Remaining() time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.cancelled || s.next >= len(s.alerts) {
+		return 0
+	}
+
+	return max(s.alerts[s.next].At-s.running(), 0)
+}
+
+// running is how long the schedule has been running, not counting pauses.
+func (s *blindSchedule) running() time.Duration {
+	if s.paused {
+		return s.elapsed
+	}
+
+	return s.elapsed + s.clock.Now().Sub(s.resumedAt)
+}
+
+func (s *blindSchedule) arm() {
+	if s.next >= len(s.alerts) {
+		return
+	}
+
+	s.generation++
+	generation := s.generation
+	s.timer = s.clock.AfterFunc(s.alerts[s.next].At-s.running(), func() {
+		s.fire(generation)
+	})
+}
+
+func (s *blindSchedule) disarm() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.generation++
+}
+
+// fire writes the alerts that are due and arms the timer for the next one.
+func (s *blindSchedule) fire(generation int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if generation != s.generation || s.paused || s.cancelled {
+		return
+	}
+
+	for s.next < len(s.alerts) && s.alerts[s.next].At <= s.running() {
+		if err := writeAlert(s.to, s.alerts[s.next]); err != nil {
+			log.Println("couldn't print current blind, cancelling the blinds: ", err)
+			s.cancelled = true
+			return
+		}
+		s.next++
+	}
+
+	s.arm()
+}
@@ -0,0 +1,133 @@
+package engine_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestAlerter(t *testing.T) {
+	alerts := []engine.BlindAlert{
+		{At: 0, Level: engine.BlindLevel{SmallBlind: 100, BigBlind: 200}},
+		{At: 10 * time.Minute, Level: engine.BlindLevel{SmallBlind: 200, BigBlind: 400}},
+		{At: 20 * time.Minute, Level: engine.BlindLevel{SmallBlind: 400, BigBlind: 800}},
+	}
+
+	t.Run("it alerts every level when it's due", func(t *testing.T) {
+		clock, out := newManualClock(), &bytes.Buffer{}
+
+		engine.NewAlerter(clock).ScheduleAlerts(alerts, out)
+
+		clock.Advance(0)
+		assertAlerted(t, out, "Blind is now 100/200")
+
+		clock.Advance(9 * time.Minute)
+		assertAlerted(t, out, "Blind is now 100/200")
+
+		clock.Advance(11 * time.Minute)
+		assertAlerted(t, out, "Blind is now 100/200", "Blind is now 200/400", "Blind is now 400/800")
+	})
+
+	t.Run("it stops alerting once cancelled", func(t *testing.T) {
+		clock, out := newManualClock(), &bytes.Buffer{}
+
+		schedule := engine.NewAlerter(clock).ScheduleAlerts(alerts, out)
+		clock.Advance(5 * time.Minute)
+		schedule.Cancel()
+		clock.Advance(time.Hour)
+
+		assertAlerted(t, out, "Blind is now 100/200")
+		assertRemaining(t, schedule, 0)
+	})
+
+	t.Run("it doesn't count the time it was paused", func(t *testing.T) {
+		clock, out := newManualClock(), &bytes.Buffer{}
+
+		schedule := engine.NewAlerter(clock).ScheduleAlerts(alerts, out)
+		clock.Advance(4 * time.Minute)
+		schedule.Pause()
+		clock.Advance(time.Hour)
+
+		assertAlerted(t, out, "Blind is now 100/200")
+		assertRemaining(t, schedule, 6*time.Minute)
+
+		schedule.Resume()
+		clock.Advance(6 * time.Minute)
+
+		assertAlerted(t, out, "Blind is now 100/200", "Blind is now 200/400")
+		assertRemaining(t, schedule, 10*time.Minute)
+	})
+
+	t.Run("it hands alerts over as they are to blind writers", func(t *testing.T) {
+		clock, out := newManualClock(), &blindWriter{}
+
+		engine.NewAlerter(clock).ScheduleAlerts(alerts, out)
+		clock.Advance(10 * time.Minute)
+
+		if len(out.alerts) != 2 || out.alerts[1] != alerts[1] {
+			t.Errorf("got alerts %v, want the first two of %v", out.alerts, alerts)
+		}
+	})
+
+	t.Run("it stops alerting once it can't write", func(t *testing.T) {
+		clock, out := newManualClock(), &failingWriter{}
+
+		schedule := engine.NewAlerter(clock).ScheduleAlerts(alerts, out)
+		clock.Advance(time.Hour)
+
+		assertRemaining(t, schedule, 0)
+		if out.writes != 1 {
+			t.Errorf("got %d writes, want 1", out.writes)
+		}
+	})
+}
+
+func newManualClock() *tests.ManualClock {
+	return tests.NewManualClock(time.Date(2026, time.March, 14, 19, 0, 0, 0, time.UTC))
+}
+
+func assertAlerted(t testing.TB, out *bytes.Buffer, want ...string) {
+	t.Helper()
+
+	got := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if out.Len() == 0 {
+		got = nil
+	}
+
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got alerts %q, want %q", got, want)
+	}
+}
+
+func assertRemaining(t testing.TB, schedule engine.BlindSchedule, want time.Duration) {
+	t.Helper()
+
+	if got := schedule.Remaining(); got != want {
+		t.Errorf("got %v until the next level, want %v", got, want)
+	}
+}
+
+type blindWriter struct {
+	io.Writer
+	alerts []engine.BlindAlert
+}
+
+func (w *blindWriter) WriteBlind(alert engine.BlindAlert) error {
+	w.alerts = append(w.alerts, alert)
+	return nil
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(_ []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("connection closed")
+}
@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StandardBlindsName names the blind structure games use unless another
+// preset is picked.
+const StandardBlindsName = "standard"
+
+var ErrUnknownBlinds = errors.New("unknown blind structure")
+
+// Duration is a time.Duration written as text, like 10m or 1h30m, in blind
+// structure files.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// BlindLevel is a stage of a game, either with the blinds and ante everyone
+// pays or a break. Levels without a big blind have the single blind of
+// SmallBlind, like those of the standard structure.
+type BlindLevel struct {
+	SmallBlind int `json:"small_blind,omitempty" yaml:"small_blind,omitempty"`
+	BigBlind   int `json:"big_blind,omitempty"   yaml:"big_blind,omitempty"`
+	Ante       int `json:"ante,omitempty"        yaml:"ante,omitempty"`
+	// Duration overrides how long the level of its structure lasts.
+	Duration Duration `json:"duration,omitempty" yaml:"duration,omitempty"`
+	Break    bool     `json:"break,omitempty"    yaml:"break,omitempty"`
+}
+
+func (l BlindLevel) String() string {
+	if l.Break {
+		return "Break"
+	}
+
+	blind := fmt.Sprintf("%d/%d", l.SmallBlind, l.BigBlind)
+	if l.BigBlind == 0 {
+		blind = fmt.Sprint(l.SmallBlind)
+	}
+
+	if l.Ante > 0 {
+		return fmt.Sprintf("Blind is now %s, ante %d", blind, l.Ante)
+	}
+
+	return "Blind is now " + blind
+}
+
+// highest is the biggest blind paid at the level.
+func (l BlindLevel) highest() int {
+	return max(l.SmallBlind, l.BigBlind)
+}
+
+// BlindStructure is how blinds go up during a game. Levels last
+// LevelDuration plus PerPlayer for every player at the start of the game,
+// unless they set a duration of their own. The last level lasts until the
+// game is over.
+type BlindStructure struct {
+	Name          string       `json:"name"                 yaml:"name"`
+	LevelDuration Duration     `json:"level_duration"       yaml:"level_duration"`
+	PerPlayer     Duration     `json:"per_player,omitempty" yaml:"per_player,omitempty"`
+	Levels        []BlindLevel `json:"levels"               yaml:"levels"`
+}
+
+// StandardBlinds raises the blind every five minutes plus a minute for every
+// player, alerting it as games always have.
+func StandardBlinds() BlindStructure {
+	blinds := BlindStructure{
+		Name:          StandardBlindsName,
+		LevelDuration: Duration(5 * time.Minute),
+		PerPlayer:     Duration(time.Minute),
+	}
+
+	for _, blind := range []int{100, 200, 300, 400, 500, 600, 800, 1000, 2000, 4000, 8000} {
+		blinds.Levels = append(blinds.Levels, BlindLevel{SmallBlind: blind})
+	}
+
+	return blinds
+}
+
+// Duration is how long level lasts in a game started with numberOfPlayers.
+func (s BlindStructure) Duration(level BlindLevel, numberOfPlayers int) time.Duration {
+	if level.Duration > 0 {
+		return time.Duration(level.Duration)
+	}
+
+	return time.Duration(s.LevelDuration) + time.Duration(numberOfPlayers)*time.Duration(s.PerPlayer)
+}
+
+// Validate reports everything wrong with the structure at once.
+func (s BlindStructure) Validate() error {
+	var errs []error
+
+	if s.Name == "" {
+		errs = append(errs, errors.New("a blind structure needs a name"))
+	}
+	if s.LevelDuration < 0 || s.PerPlayer < 0 {
+		errs = append(errs, errors.New("level durations can't be negative"))
+	}
+	if len(s.Levels) == 0 {
+		errs = append(errs, errors.New("a blind structure needs levels"))
+	}
+
+	var previous BlindLevel
+	for i, level := range s.Levels {
+		if err := s.validateLevel(level, previous); err != nil {
+			errs = append(errs, fmt.Errorf("level %d: %w", i+1, err))
+		}
+		if !level.Break {
+			previous = level
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid blind structure %q: %w", s.Name, err)
+	}
+
+	return nil
+}
+
+func (s BlindStructure) validateLevel(level, previous BlindLevel) error {
+	switch {
+	case level.Duration < 0:
+		return errors.New("duration can't be negative")
+	case level.Duration == 0 && s.LevelDuration == 0 && s.PerPlayer == 0:
+		return errors.New("needs a duration, as the structure doesn't set one")
+	case level.Break && (level.SmallBlind != 0 || level.BigBlind != 0 || level.Ante != 0):
+		return errors.New("breaks don't have blinds")
+	case level.Break:
+		return nil
+	case level.SmallBlind <= 0:
+		return errors.New("small blind must be positive")
+	case level.BigBlind != 0 && level.BigBlind < level.SmallBlind:
+		return errors.New("big blind can't be smaller than the small blind")
+	case level.Ante < 0:
+		return errors.New("ante can't be negative")
+	case level.highest() < previous.highest():
+		return errors.New("blinds can't go down")
+	}
+
+	return nil
+}
+
+// BlindPresets are the blind structures players can pick from, by name.
+type BlindPresets map[string]BlindStructure
+
+// DefaultBlindPresets only has the standard blind structure.
+func DefaultBlindPresets() BlindPresets {
+	return BlindPresets{StandardBlindsName: StandardBlinds()}
+}
+
+// Get returns the preset called name, or the standard one when name is empty.
+func (p BlindPresets) Get(name string) (BlindStructure, error) {
+	if name == "" {
+		name = StandardBlindsName
+	}
+
+	blinds, ok := p[name]
+	if !ok {
+		return BlindStructure{}, fmt.Errorf("%w %q, want one of %s", ErrUnknownBlinds, name, strings.Join(p.Names(), ", "))
+	}
+
+	return blinds, nil
+}
+
+func (p BlindPresets) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// LoadBlindPresets adds the blind structures in the .yaml, .yml and .json
+// files of dir to the default presets. A structure without a name is named
+// after its file. A missing dir only leaves the defaults.
+func LoadBlindPresets(dir string) (BlindPresets, error) {
+	presets := DefaultBlindPresets()
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the blind structures in %s: %w", dir, err)
+	}
+
+	loaded := map[string]string{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		blinds, err := ReadBlindStructure(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := loaded[blinds.Name]; ok {
+			return nil, fmt.Errorf("blind structure %q is defined in both %s and %s", blinds.Name, other, path)
+		}
+		loaded[blinds.Name] = path
+		presets[blinds.Name] = blinds
+	}
+
+	return presets, nil
+}
+
+// ReadBlindStructure reads and validates the blind structure in the YAML or
+// JSON file at path.
+func ReadBlindStructure(path string) (BlindStructure, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- presets are read from the configured directory
+	if err != nil {
+		return BlindStructure{}, fmt.Errorf("couldn't read blind structure %s: %w", path, err)
+	}
+
+	var blinds BlindStructure
+	if filepath.Ext(path) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&blinds)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&blinds)
+	}
+	if err != nil {
+		return BlindStructure{}, fmt.Errorf("problem parsing blind structure %s: %w", path, err)
+	}
+
+	if blinds.Name == "" {
+		blinds.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if err := blinds.Validate(); err != nil {
+		return BlindStructure{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return blinds, nil
+}
@@ -0,0 +1,109 @@
+package engine_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestLoadBlindPresets(t *testing.T) {
+	t.Run("it reads YAML and JSON presets next to the standard one", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "turbo.yaml"), `
+level_duration: 3m
+levels:
+  - {small_blind: 25, big_blind: 50}
+  - {break: true, duration: 5m}
+  - {small_blind: 50, big_blind: 100, ante: 10}
+`)
+		writeFile(t, filepath.Join(dir, "slow.json"), `{
+  "name": "deep-stack",
+  "level_duration": "15m",
+  "levels": [{"small_blind": 25, "big_blind": 50}]
+}`)
+		writeFile(t, filepath.Join(dir, "single.yaml"), "level_duration: 5m\nlevels: [{small_blind: 100}, {small_blind: 200, ante: 25}]")
+		writeFile(t, filepath.Join(dir, "notes.txt"), "not a preset")
+
+		presets, err := engine.LoadBlindPresets(dir)
+		tests.AssertNoError(t, err)
+
+		if names := presets.Names(); !slices.Equal(names, []string{"deep-stack", "single", engine.StandardBlindsName, "turbo"}) {
+			t.Fatalf("got presets %v, want deep-stack, single, standard and turbo", names)
+		}
+
+		single, err := presets.Get("single")
+		tests.AssertNoError(t, err)
+		if got := single.Levels[1].String(); got != "Blind is now 200, ante 25" {
+			t.Errorf("got alert %q, want %q", got, "Blind is now 200, ante 25")
+		}
+
+		turbo, err := presets.Get("turbo")
+		tests.AssertNoError(t, err)
+		if got := turbo.Duration(turbo.Levels[1], 6); got != 5*time.Minute {
+			t.Errorf("got a break of %v, want 5m", got)
+		}
+		if got := turbo.Duration(turbo.Levels[2], 6); got != 3*time.Minute {
+			t.Errorf("got a level of %v, want 3m", got)
+		}
+	})
+
+	t.Run("it only has the standard preset without a directory", func(t *testing.T) {
+		presets, err := engine.LoadBlindPresets(filepath.Join(t.TempDir(), "missing"))
+		tests.AssertNoError(t, err)
+
+		standard, err := presets.Get("")
+		tests.AssertNoError(t, err)
+		if got := standard.Duration(standard.Levels[0], 5); got != 10*time.Minute {
+			t.Errorf("got standard levels of %v for 5 players, want 10m", got)
+		}
+		if got := standard.Levels[0].String(); got != "Blind is now 100" {
+			t.Errorf("got standard alert %q, want %q", got, "Blind is now 100")
+		}
+		tests.AssertNoError(t, standard.Validate())
+	})
+
+	t.Run("it refuses invalid presets", func(t *testing.T) {
+		cases := map[string]string{
+			"blinds going down":    "level_duration: 5m\nlevels: [{small_blind: 100, big_blind: 200}, {small_blind: 50, big_blind: 100}]",
+			"no levels":            "level_duration: 5m\nlevels: []",
+			"no durations":         "levels: [{small_blind: 100, big_blind: 200}]",
+			"break with blinds":    "level_duration: 5m\nlevels: [{break: true, small_blind: 100}]",
+			"big below small":      "level_duration: 5m\nlevels: [{small_blind: 100, big_blind: 50}]",
+			"unknown field":        "level_duration: 5m\nlevels: [{small: 100, big_blind: 200}]",
+			"unparsable durations": "level_duration: five minutes\nlevels: [{small_blind: 100, big_blind: 200}]",
+		}
+
+		for name, preset := range cases {
+			t.Run(name, func(t *testing.T) {
+				dir := t.TempDir()
+				writeFile(t, filepath.Join(dir, "bad.yaml"), preset)
+
+				if _, err := engine.LoadBlindPresets(dir); err == nil {
+					t.Error("expected an error but didn't get one")
+				}
+			})
+		}
+	})
+
+	t.Run("it refuses unknown presets", func(t *testing.T) {
+		_, err := engine.DefaultBlindPresets().Get("glacial")
+
+		if !errors.Is(err, engine.ErrUnknownBlinds) {
+			t.Errorf("got error %v, want %v", err, engine.ErrUnknownBlinds)
+		}
+	})
+}
+
+func writeFile(t testing.TB, path, data string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("couldn't write %s: %v", path, err)
+	}
+}
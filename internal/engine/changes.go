@@ -0,0 +1,44 @@
+package engine
+
+import "sync"
+
+// Changes keeps the subscribers of a ChangeNotifier. The zero value is ready
+// to use.
+type Changes struct {
+	lock        sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+func (c *Changes) Subscribe() (<-chan struct{}, func()) {
+	changes := make(chan struct{}, 1)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.subscribers == nil {
+		c.subscribers = map[chan struct{}]struct{}{}
+	}
+	c.subscribers[changes] = struct{}{}
+
+	cancel := func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		delete(c.subscribers, changes)
+	}
+
+	return changes, cancel
+}
+
+// Notify tells every subscriber something changed, without waiting for those
+// who haven't read the last change yet.
+func (c *Changes) Notify() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for changes := range c.subscribers {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+func TestChanges(t *testing.T) {
+	t.Run("it tells every subscriber, merging unread changes", func(t *testing.T) {
+		var changes engine.Changes
+		first, cancelFirst := changes.Subscribe()
+		defer cancelFirst()
+		second, cancelSecond := changes.Subscribe()
+		defer cancelSecond()
+
+		changes.Notify()
+		changes.Notify()
+
+		for _, subscriber := range []<-chan struct{}{first, second} {
+			assertChanges(t, subscriber, 1)
+		}
+	})
+
+	t.Run("it stops telling subscribers who cancelled", func(t *testing.T) {
+		var changes engine.Changes
+		subscriber, cancel := changes.Subscribe()
+
+		cancel()
+		changes.Notify()
+
+		assertChanges(t, subscriber, 0)
+	})
+}
+
+func assertChanges(t testing.TB, subscriber <-chan struct{}, want int) {
+	t.Helper()
+
+	got := 0
+	for {
+		select {
+		case <-subscriber:
+			got++
+			continue
+		default:
+		}
+		break
+	}
+
+	if got != want {
+		t.Errorf("got %d changes, want %d", got, want)
+	}
+}
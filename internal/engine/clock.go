@@ -0,0 +1,28 @@
+package engine
+
+import "time"
+
+// Clock tells the time to games and their blinds, so they can be run on
+// something other than the wall clock.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f once d has passed.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a call scheduled with Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the call, reporting false if it already happened.
+	Stop() bool
+}
+
+// SystemClock is the wall clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
@@ -0,0 +1,251 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// The actions a Correction takes.
+const (
+	// ActionVoid deletes a match, taking its win away from the winner.
+	ActionVoid = "void"
+	// ActionReassign gives the win of a match to someone else.
+	ActionReassign = "reassign"
+	// ActionAdjust changes the wins of a player without touching any match.
+	ActionAdjust = "adjust"
+	// ActionMerge records every match and win of a player under the name of
+	// another, for players recorded under more than one name.
+	ActionMerge = "merge"
+)
+
+var (
+	ErrMatchNotFound = errors.New("match not found")
+	ErrBadCorrection = errors.New("bad correction")
+	ErrSeasonClosed  = errors.New("match was played in a season already closed")
+)
+
+// Correction fixes a result recorded by mistake.
+type Correction struct {
+	Action string `json:"action"`
+	// Match is the ID of the match voided or reassigned.
+	Match string `json:"match,omitempty"`
+	// Winner is who a match is reassigned to.
+	Winner string `json:"winner,omitempty"`
+	// Player is whose wins are adjusted, by Wins, or who is merged into
+	// Into. Merging takes every name with the same PlayerID as Player.
+	Player string `json:"player,omitempty"`
+	Wins   int    `json:"wins,omitempty"`
+	Into   string `json:"into,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Results are what a correction changed, as it was before or after it: the
+// match, unless the wins were adjusted or it was voided, and the wins of the
+// players.
+type Results struct {
+	Match *Match         `json:"match,omitempty"`
+	Wins  map[string]int `json:"wins"`
+}
+
+// AuditEntry records who made a correction and when, along with what it
+// changed. Entries are only ever appended to the audit log.
+type AuditEntry struct {
+	Seq int `json:"seq"`
+	Correction
+	By     string    `json:"by"`
+	At     time.Time `json:"at"`
+	Before Results   `json:"before"`
+	After  Results   `json:"after"`
+}
+
+// Corrector is a PlayerStore whose results can be corrected, keeping an
+// audit log of every correction.
+type Corrector interface {
+	// Correct applies correction, recording who made it and when in the
+	// audit log, and returns the entry recording it.
+	Correct(ctx context.Context, correction Correction, by string, at time.Time) (AuditEntry, error)
+	// GetAudit returns the audit log, oldest entry first.
+	GetAudit(ctx context.Context) ([]AuditEntry, error)
+}
+
+// Correct works out the audit entry numbered seq recording correction to the
+// matches and league of the season running after those closed, with by
+// naming who made it and at when. Applying the entry gives the corrected
+// matches and league.
+func Correct(correction Correction, matches []Match, league League, closed []Season, by string, at time.Time, seq int) (AuditEntry, error) {
+	entry := AuditEntry{
+		Seq:        seq,
+		Correction: correction,
+		By:         by,
+		At:         at,
+		Before:     Results{Wins: map[string]int{}},
+		After:      Results{Wins: map[string]int{}},
+	}
+
+	wins := func(name string) int {
+		if player := league.Find(name); player != nil {
+			return player.Wins
+		}
+		return 0
+	}
+
+	switch correction.Action {
+	case ActionVoid, ActionReassign:
+		match, err := correctable(correction.Match, matches, closed)
+		if err != nil {
+			return AuditEntry{}, err
+		}
+		entry.Before.Match = &match
+		entry.Before.Wins[match.Winner] = wins(match.Winner)
+		entry.After.Wins[match.Winner] = max(wins(match.Winner)-1, 0)
+
+		if correction.Action == ActionVoid {
+			break
+		}
+
+		if err := ValidatePlayerName(correction.Winner); err != nil {
+			return AuditEntry{}, err
+		}
+		if correction.Winner == match.Winner {
+			return AuditEntry{}, fmt.Errorf("%w, %s already won match %s", ErrBadCorrection, match.Winner, match.ID)
+		}
+		if len(match.Participants) > 0 && !slices.Contains(match.Participants, correction.Winner) {
+			return AuditEntry{}, fmt.Errorf("%w, %s didn't play match %s", ErrBadCorrection, correction.Winner, match.ID)
+		}
+
+		reassigned := reassign(match, correction.Winner)
+		entry.After.Match = &reassigned
+		entry.Before.Wins[correction.Winner] = wins(correction.Winner)
+		entry.After.Wins[correction.Winner] = wins(correction.Winner) + 1
+
+	case ActionAdjust:
+		if err := ValidatePlayerName(correction.Player); err != nil {
+			return AuditEntry{}, err
+		}
+		if correction.Wins == 0 {
+			return AuditEntry{}, fmt.Errorf("%w, adjusting wins takes a number of them to add or take away", ErrBadCorrection)
+		}
+		if wins(correction.Player)+correction.Wins < 0 {
+			return AuditEntry{}, fmt.Errorf("%w, %s only has %d wins", ErrBadCorrection, correction.Player, wins(correction.Player))
+		}
+		entry.Before.Wins[correction.Player] = wins(correction.Player)
+		entry.After.Wins[correction.Player] = wins(correction.Player) + correction.Wins
+
+	case ActionMerge:
+		if NormalizeName(correction.Player) == "" {
+			return AuditEntry{}, fmt.Errorf("%w, merging takes a player to merge", ErrBadCorrection)
+		}
+		if err := ValidatePlayerName(correction.Into); err != nil {
+			return AuditEntry{}, err
+		}
+
+		entry.Before.Wins[correction.Into] = wins(correction.Into)
+		entry.After.Wins[correction.Into] = wins(correction.Into)
+		for _, player := range league {
+			if merged(correction, player.Name) {
+				entry.Before.Wins[player.Name] = player.Wins
+				entry.After.Wins[player.Name] = 0
+				entry.After.Wins[correction.Into] += player.Wins
+			}
+		}
+
+	default:
+		return AuditEntry{}, fmt.Errorf("%w, the action is %s, %s, %s or %s, got %q", ErrBadCorrection, ActionVoid, ActionReassign, ActionAdjust, ActionMerge, correction.Action)
+	}
+
+	return entry, nil
+}
+
+// Apply returns matches and league as they are after the correction of the
+// entry, leaving them untouched.
+func (e AuditEntry) Apply(matches []Match, league League) ([]Match, League) {
+	corrected := make([]Match, 0, len(matches))
+	for _, match := range matches {
+		switch {
+		case e.Action == ActionMerge:
+			corrected = append(corrected, merge(match, e.Correction))
+		case e.Before.Match == nil || match.ID != e.Before.Match.ID:
+			corrected = append(corrected, match)
+		case e.After.Match != nil:
+			corrected = append(corrected, *e.After.Match)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(e.After.Wins)) {
+		league = league.WithWins(name, e.After.Wins[name])
+	}
+
+	return corrected, league
+}
+
+// merged reports whether name is one of those merging by correction takes.
+func merged(correction Correction, name string) bool {
+	return name != correction.Into && PlayerID(name) == PlayerID(correction.Player)
+}
+
+// merge returns a copy of match with the names merging by correction takes
+// replaced by who they're merged into.
+func merge(match Match, correction Correction) Match {
+	rename := func(names []string) []string {
+		if names == nil {
+			return nil
+		}
+
+		renamed := make([]string, 0, len(names))
+		for _, name := range names {
+			if merged(correction, name) {
+				name = correction.Into
+			}
+			// someone recorded under both names is only kept once
+			if !slices.Contains(renamed, name) {
+				renamed = append(renamed, name)
+			}
+		}
+		return renamed
+	}
+
+	if merged(correction, match.Winner) {
+		match.Winner = correction.Into
+	}
+	match.Participants = rename(match.Participants)
+	match.FinishingOrder = rename(match.FinishingOrder)
+	return match
+}
+
+// correctable returns the match with id, as long as it was played in the
+// season still running after those closed.
+func correctable(id string, matches []Match, closed []Season) (Match, error) {
+	i := slices.IndexFunc(matches, func(m Match) bool {
+		return m.ID == id
+	})
+	if i < 0 {
+		return Match{}, fmt.Errorf("%w: %s", ErrMatchNotFound, id)
+	}
+
+	if len(closed) > 0 && !matches[i].FinishedAt.After(closed[len(closed)-1].EndedAt) {
+		return Match{}, fmt.Errorf("%w: %s", ErrSeasonClosed, id)
+	}
+
+	return matches[i], nil
+}
+
+// reassign returns a copy of match won by winner, who swaps places with the
+// old winner in the finishing order.
+func reassign(match Match, winner string) Match {
+	order := slices.Clone(match.FinishingOrder)
+
+	if was := slices.Index(order, match.Winner); was >= 0 {
+		if at := slices.Index(order, winner); at >= 0 {
+			order[at] = match.Winner
+		}
+		order[was] = winner
+	}
+
+	match.FinishingOrder = order
+	match.Winner = winner
+	return match
+}
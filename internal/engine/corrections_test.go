@@ -0,0 +1,77 @@
+package engine_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestCorrect(t *testing.T) {
+	at := time.Date(2026, time.May, 2, 21, 0, 0, 0, time.UTC)
+	matches := []engine.Match{
+		{ID: "old", FinishedAt: at.AddDate(0, -1, 0), Winner: "Cleo"},
+		{ID: "seated", FinishedAt: at, Participants: []string{"Cleo", "Chris", "Pepper"}, FinishingOrder: []string{"Cleo", "Pepper", "Chris"}, Winner: "Cleo"},
+		{ID: "manual", FinishedAt: at, FinishingOrder: []string{"Chris"}, Winner: "Chris"},
+	}
+	league := engine.League{{Name: "Cleo", Wins: 1}, {Name: "Chris", Wins: 1}}
+	closed := []engine.Season{{ID: "2026-Q1", EndedAt: at.AddDate(0, 0, -7)}}
+
+	t.Run("voiding a match takes its win away", func(t *testing.T) {
+		entry, err := engine.Correct(engine.Correction{Action: engine.ActionVoid, Match: "manual"}, matches, league, closed, "admin", at, 1)
+		tests.AssertNoError(t, err)
+
+		gotMatches, gotLeague := entry.Apply(matches, league)
+
+		if len(gotMatches) != 2 || gotMatches[1].ID != "seated" {
+			t.Errorf("got matches %+v, want the manual one gone", gotMatches)
+		}
+		if !reflect.DeepEqual(gotLeague, engine.League{{Name: "Cleo", Wins: 1}}) {
+			t.Errorf("got league %v, want only Cleo left", gotLeague)
+		}
+		if len(matches) != 3 || len(league) != 2 {
+			t.Error("changed the matches or league it was given")
+		}
+	})
+
+	t.Run("reassigning a match swaps the winners", func(t *testing.T) {
+		entry, err := engine.Correct(engine.Correction{Action: engine.ActionReassign, Match: "seated", Winner: "Pepper"}, matches, league, closed, "admin", at, 1)
+		tests.AssertNoError(t, err)
+
+		want := map[string]int{"Cleo": 0, "Pepper": 1}
+		if !reflect.DeepEqual(entry.After.Wins, want) {
+			t.Errorf("got wins %v after, want %v", entry.After.Wins, want)
+		}
+		if order := entry.After.Match.FinishingOrder; !reflect.DeepEqual(order, []string{"Pepper", "Cleo", "Chris"}) {
+			t.Errorf("got finishing order %v, want Pepper and Cleo swapped", order)
+		}
+		if entry.Before.Match.Winner != "Cleo" {
+			t.Errorf("got winner %s before, want Cleo", entry.Before.Match.Winner)
+		}
+	})
+
+	t.Run("it refuses what can't be corrected", func(t *testing.T) {
+		cases := map[string]struct {
+			correction engine.Correction
+			want       error
+		}{
+			"unknown match":         {engine.Correction{Action: engine.ActionVoid, Match: "nope"}, engine.ErrMatchNotFound},
+			"closed season":         {engine.Correction{Action: engine.ActionVoid, Match: "old"}, engine.ErrSeasonClosed},
+			"same winner":           {engine.Correction{Action: engine.ActionReassign, Match: "seated", Winner: "Cleo"}, engine.ErrBadCorrection},
+			"winner who didn't sit": {engine.Correction{Action: engine.ActionReassign, Match: "seated", Winner: "Floyd"}, engine.ErrBadCorrection},
+			"bad winner":            {engine.Correction{Action: engine.ActionReassign, Match: "manual", Winner: " "}, engine.ErrBadPlayerName},
+			"no wins":               {engine.Correction{Action: engine.ActionAdjust, Player: "Cleo"}, engine.ErrBadCorrection},
+			"too few wins":          {engine.Correction{Action: engine.ActionAdjust, Player: "Cleo", Wins: -2}, engine.ErrBadCorrection},
+			"unknown action":        {engine.Correction{Action: "undo", Match: "manual"}, engine.ErrBadCorrection},
+		}
+
+		for name, c := range cases {
+			if _, err := engine.Correct(c.correction, matches, league, closed, "admin", at, 1); !errors.Is(err, c.want) {
+				t.Errorf("%s: got error %v, want %v", name, err, c.want)
+			}
+		}
+	})
+}
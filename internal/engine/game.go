@@ -1,8 +1,61 @@
 package engine
 
-import "io"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+var ErrNotParticipant = errors.New("the winner didn't play the game")
 
 type Game interface {
-	Start(numberOfPlayers int, alertDestination io.Writer)
-	Finish(winner string)
+	// Start starts a game of numberOfPlayers, who are participants when
+	// they're named.
+	Start(numberOfPlayers int, participants []string, blinds BlindStructure, alertDestination io.Writer)
+	// Finish records winner as the winner of the game. Winners who aren't
+	// among its participants are refused with ErrNotParticipant, unless the
+	// win is confirmed, when they're recorded as having played too.
+	Finish(ctx context.Context, winner string, confirmed bool) error
+	// Stop ends the game without recording it.
+	Stop()
+	// Blinds controls the blinds of the running game, nil if there is none.
+	Blinds() BlindSchedule
+}
+
+// ParseParticipants returns the names of the players of a game, trimmed and
+// spaced as names are recorded, refusing bad names and anyone named twice.
+func ParseParticipants(names []string) ([]string, error) {
+	participants := make([]string, 0, len(names))
+	for _, name := range names {
+		name = NormalizeName(name)
+		if err := ValidatePlayerName(name); err != nil {
+			return nil, err
+		}
+
+		if slices.ContainsFunc(participants, func(p string) bool { return PlayerID(p) == PlayerID(name) }) {
+			return nil, fmt.Errorf("%w, %s is playing twice", ErrBadPlayerName, name)
+		}
+		participants = append(participants, name)
+	}
+
+	return participants, nil
+}
+
+// SeatedWinner returns winner as they're named among participants, however
+// it's spaced or capitalized, or ErrNotParticipant. Anyone can win a game
+// whose participants weren't named.
+func SeatedWinner(participants []string, winner string) (string, error) {
+	if len(participants) == 0 {
+		return winner, nil
+	}
+
+	for _, participant := range participants {
+		if PlayerID(participant) == PlayerID(winner) {
+			return participant, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w, %s isn't one of %d players", ErrNotParticipant, NormalizeName(winner), len(participants))
 }
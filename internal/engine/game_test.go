@@ -0,0 +1,50 @@
+package engine_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestParticipants(t *testing.T) {
+	t.Run("it spaces the names of the participants as they're recorded", func(t *testing.T) {
+		participants, err := engine.ParseParticipants([]string{" Ruth ", "Cleo  Patra"})
+		tests.AssertNoError(t, err)
+
+		if !slices.Equal(participants, []string{"Ruth", "Cleo Patra"}) {
+			t.Errorf("got %q, want Ruth and Cleo Patra", participants)
+		}
+	})
+
+	t.Run("it refuses bad names and players named twice", func(t *testing.T) {
+		for _, names := range [][]string{{"Ruth", " "}, {"Ruth", "RUTH"}} {
+			if _, err := engine.ParseParticipants(names); !errors.Is(err, engine.ErrBadPlayerName) {
+				t.Errorf("got error %v for %q, want %v", err, names, engine.ErrBadPlayerName)
+			}
+		}
+	})
+
+	t.Run("it finds the winner among the participants", func(t *testing.T) {
+		winner, err := engine.SeatedWinner([]string{"Ruth", "Cleo"}, "cleo")
+		tests.AssertNoError(t, err)
+
+		if winner != "Cleo" {
+			t.Errorf("got winner %q, want Cleo", winner)
+		}
+		if _, err := engine.SeatedWinner([]string{"Ruth", "Cleo"}, "Floyd"); !errors.Is(err, engine.ErrNotParticipant) {
+			t.Errorf("got error %v, want %v", err, engine.ErrNotParticipant)
+		}
+	})
+
+	t.Run("anyone wins a game whose players weren't named", func(t *testing.T) {
+		winner, err := engine.SeatedWinner(nil, "Floyd")
+		tests.AssertNoError(t, err)
+
+		if winner != "Floyd" {
+			t.Errorf("got winner %q, want Floyd", winner)
+		}
+	})
+}
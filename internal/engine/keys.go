@@ -0,0 +1,106 @@
+package engine
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrBadKey      = errors.New("bad API key")
+	ErrKeyNotFound = errors.New("API key not found")
+	ErrBadKeyName  = errors.New("API key names are 1 to 64 characters")
+)
+
+// keyPrefix starts every API key, so a leaked one can be recognized.
+const keyPrefix = "gss_"
+
+// APIKey lets whoever holds its secret do what its role allows in a league.
+// Only the hash of the secret is kept, the secret itself is shown once when
+// the key is made.
+type APIKey struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Role is empty for keys made before there were roles, which could do
+	// anything.
+	Role      Role      `json:"role,omitempty"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Allowed returns the role of the key.
+func (k APIKey) Allowed() Role {
+	if k.Role == "" {
+		return RoleAdmin
+	}
+
+	return k.Role
+}
+
+// KeyStore keeps the API keys of a league.
+type KeyStore interface {
+	AddKey(ctx context.Context, key APIKey) error
+	ListKeys(ctx context.Context) ([]APIKey, error)
+	// RevokeKey removes key id, or returns ErrKeyNotFound.
+	RevokeKey(ctx context.Context, id string) error
+}
+
+// KeyedLeagues are Leagues keeping API keys for every league.
+type KeyedLeagues interface {
+	Keys(ctx context.Context, id string) (KeyStore, error)
+}
+
+// NewAPIKey makes a key named name allowed what role is, returning it with
+// its secret.
+func NewAPIKey(name string, role Role, at time.Time) (APIKey, string, error) {
+	if name == "" || name != strings.TrimSpace(name) || utf8.RuneCountInString(name) > 64 {
+		return APIKey{}, "", fmt.Errorf("%w, got %q", ErrBadKeyName, name)
+	}
+
+	if _, err := ParseRole(string(role)); err != nil {
+		return APIKey{}, "", err
+	}
+
+	id := make([]byte, 4)
+	secret := make([]byte, 32)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(id)
+	_, _ = rand.Read(secret)
+
+	key := APIKey{ID: hex.EncodeToString(id), Name: name, Role: role, CreatedAt: at}
+	plain := keyPrefix + key.ID + "_" + hex.EncodeToString(secret)
+	key.Hash = HashAPIKey(plain)
+
+	return key, plain, nil
+}
+
+// HashAPIKey hashes secret for keeping. The secrets are random enough that a
+// plain hash can't be brute forced, unlike a password.
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckAPIKey returns the key of keys secret belongs to, or ErrBadKey.
+func CheckAPIKey(ctx context.Context, keys KeyStore, secret string) (APIKey, error) {
+	all, err := keys.ListKeys(ctx)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	hash := []byte(HashAPIKey(secret))
+	for _, key := range all {
+		if subtle.ConstantTimeCompare(hash, []byte(key.Hash)) == 1 {
+			return key, nil
+		}
+	}
+
+	return APIKey{}, ErrBadKey
+}
@@ -0,0 +1,64 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+
+	key, secret, err := engine.NewAPIKey("scorekeeper", engine.RoleScorekeeper, time.Now())
+	tests.AssertNoError(t, err)
+	other, _, err := engine.NewAPIKey("scoreboard", engine.RoleViewer, time.Now())
+	tests.AssertNoError(t, err)
+
+	keys := &tests.StubKeyStore{Keys: []engine.APIKey{other, key}}
+
+	t.Run("it only keeps the hash of the secret", func(t *testing.T) {
+		if strings.Contains(key.Hash, secret) || key.Hash != engine.HashAPIKey(secret) {
+			t.Errorf("got hash %q for secret %q, want its hash", key.Hash, secret)
+		}
+	})
+
+	t.Run("it finds the key of a secret", func(t *testing.T) {
+		got, err := engine.CheckAPIKey(ctx, keys, secret)
+		tests.AssertNoError(t, err)
+
+		if got.ID != key.ID || got.Name != "scorekeeper" || got.Allowed() != engine.RoleScorekeeper {
+			t.Errorf("got key %+v, want %+v", got, key)
+		}
+	})
+
+	t.Run("it refuses secrets of no key", func(t *testing.T) {
+		for _, secret := range []string{"", key.Hash, secret + "x"} {
+			if _, err := engine.CheckAPIKey(ctx, keys, secret); !errors.Is(err, engine.ErrBadKey) {
+				t.Errorf("got error %v for %q, want %v", err, secret, engine.ErrBadKey)
+			}
+		}
+	})
+
+	t.Run("it lets keys made before roles do anything", func(t *testing.T) {
+		if got := (engine.APIKey{}).Allowed(); got != engine.RoleAdmin {
+			t.Errorf("got role %s, want %s", got, engine.RoleAdmin)
+		}
+
+		if _, _, err := engine.NewAPIKey("scoreboard", "", time.Now()); !errors.Is(err, engine.ErrBadRole) {
+			t.Errorf("got error %v making a key without a role, want %v", err, engine.ErrBadRole)
+		}
+	})
+
+	t.Run("it refuses bad names", func(t *testing.T) {
+		for _, name := range []string{"", " spaced", strings.Repeat("x", 65)} {
+			if _, _, err := engine.NewAPIKey(name, engine.RoleViewer, time.Now()); !errors.Is(err, engine.ErrBadKeyName) {
+				t.Errorf("got error %v for %q, want %v", err, name, engine.ErrBadKeyName)
+			}
+		}
+	})
+}
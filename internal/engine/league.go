@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
+	"sort"
 )
 
 type Player struct {
 	Name string
 	Wins int
+	// Rating is only filled in for leagues ordered by rating.
+	Rating float64 `json:",omitempty"`
 }
 
 type League []Player
@@ -24,6 +28,56 @@ func (l League) Find(name string) *Player {
 	return nil
 }
 
+// AddWin credits name with a win, adding them to the league if they aren't in
+// it yet.
+func (l League) AddWin(name string) League {
+	if player := l.Find(name); player != nil {
+		player.Wins++
+		return l
+	}
+
+	return append(l, Player{Name: name, Wins: 1})
+}
+
+// WithWins returns a copy of the league where name has wins, leaving them out
+// of it without any.
+func (l League) WithWins(name string, wins int) League {
+	league := make(League, 0, len(l)+1)
+	for _, player := range l {
+		if player.Name != name {
+			league = append(league, player)
+		}
+	}
+
+	if wins <= 0 {
+		return league
+	}
+
+	if i := slices.IndexFunc(l, func(p Player) bool { return p.Name == name }); i >= 0 {
+		player := l[i]
+		player.Wins = wins
+		return slices.Insert(league, i, player)
+	}
+
+	return append(league, Player{Name: name, Wins: wins})
+}
+
+// Sorted returns a copy of the league ordered by wins, most first, and by
+// name between players with as many wins.
+func (l League) Sorted() League {
+	sorted := make(League, len(l))
+	copy(sorted, l)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Wins != sorted[j].Wins {
+			return sorted[i].Wins > sorted[j].Wins
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return sorted
+}
+
 func NewLeague(reader io.Reader) (League, error) {
 	var league League
 	err := json.NewDecoder(reader).Decode(&league)
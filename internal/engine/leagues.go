@@ -0,0 +1,33 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var (
+	ErrLeagueExists   = errors.New("league already exists")
+	ErrLeagueNotFound = errors.New("league not found")
+	ErrBadLeagueID    = errors.New("league ids are 1 to 64 lowercase letters, digits and dashes")
+)
+
+var leagueID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Leagues keeps several named leagues, each in a store of its own.
+type Leagues interface {
+	CreateLeague(ctx context.Context, id string) error
+	ListLeagues(ctx context.Context) ([]string, error)
+	Store(ctx context.Context, id string) (PlayerStore, error)
+}
+
+// ValidateLeagueID makes sure id can name a league, and whatever a store
+// derives from it, like a file name.
+func ValidateLeagueID(id string) error {
+	if !leagueID.MatchString(id) {
+		return fmt.Errorf("%w, got %q", ErrBadLeagueID, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// GameManual marks a win recorded directly rather than by playing a game.
+const GameManual = "manual"
+
+// Match is the record of a single finished game.
+type Match struct {
+	ID             string    `json:"id"`
+	Game           string    `json:"game"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	Entrants       int       `json:"entrants"`
+	Participants   []string  `json:"participants,omitempty"`
+	FinishingOrder []string  `json:"finishing_order,omitempty"`
+	Winner         string    `json:"winner"`
+}
+
+// ManualWin is the match recorded for a win awarded outside of a game.
+func ManualWin(winner string, at time.Time) Match {
+	return Match{
+		ID:             NewMatchID(),
+		Game:           GameManual,
+		StartedAt:      at,
+		FinishedAt:     at,
+		FinishingOrder: []string{winner},
+		Winner:         winner,
+	}
+}
+
+func NewMatchID() string {
+	id := make([]byte, 16)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// LeagueFromMatches tallies the wins of every match winner.
+func LeagueFromMatches(matches []Match) League {
+	league := League{}
+
+	for _, match := range matches {
+		league = league.AddWin(match.Winner)
+	}
+
+	return league
+}
@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxPlayerNameLength is the most runes a player name can have.
+const MaxPlayerNameLength = 64
+
+var ErrBadPlayerName = errors.New("player names are 1 to 64 printable characters, without slashes or surrounding spaces")
+
+// ValidatePlayerName makes sure name can be recorded as a player.
+func ValidatePlayerName(name string) error {
+	valid := name != "" &&
+		name == strings.TrimSpace(name) &&
+		utf8.ValidString(name) &&
+		utf8.RuneCountInString(name) <= MaxPlayerNameLength &&
+		!strings.ContainsRune(name, '/') &&
+		!strings.ContainsFunc(name, func(r rune) bool { return !unicode.IsPrint(r) })
+
+	if !valid {
+		return fmt.Errorf("%w, got %q", ErrBadPlayerName, name)
+	}
+
+	return nil
+}
+
+// Played reports whether name won or took part in any of matches.
+func Played(matches []Match, name string) bool {
+	return slices.ContainsFunc(matches, func(m Match) bool {
+		return m.Winner == name || slices.Contains(m.Participants, name) || slices.Contains(m.FinishingOrder, name)
+	})
+}
@@ -0,0 +1,37 @@
+package engine_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+func TestValidatePlayerName(t *testing.T) {
+	for _, name := range []string{"Pepper", "Mary Ann", "Zoë", "o'brien", strings.Repeat("x", engine.MaxPlayerNameLength)} {
+		if err := engine.ValidatePlayerName(name); err != nil {
+			t.Errorf("got error %v for %q, want it valid", err, name)
+		}
+	}
+
+	for _, name := range []string{"", " Pepper", "Pepper ", "a/b", "tab\there", "\xff", strings.Repeat("x", engine.MaxPlayerNameLength+1)} {
+		if err := engine.ValidatePlayerName(name); !errors.Is(err, engine.ErrBadPlayerName) {
+			t.Errorf("got error %v for %q, want %v", err, name, engine.ErrBadPlayerName)
+		}
+	}
+}
+
+func TestPlayed(t *testing.T) {
+	matches := []engine.Match{
+		{Winner: "Cleo", Participants: []string{"Cleo", "Chris"}},
+		engine.ManualWin("Pepper", time.Now()),
+	}
+
+	for name, want := range map[string]bool{"Cleo": true, "Chris": true, "Pepper": true, "Floyd": false} {
+		if got := engine.Played(matches, name); got != want {
+			t.Errorf("got %v for %s, want %v", got, name, want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+const (
+	DefaultRating  = 1500
+	DefaultKFactor = 32
+)
+
+// Ratings maps player names to their skill rating.
+type Ratings map[string]float64
+
+// Elo rates players by treating every match as a round of head-to-head games
+// between its participants: whoever finished ahead beat whoever finished
+// behind. Participants missing from the finishing order are tied behind
+// everyone placed.
+type Elo struct {
+	// K is the most a rating can move in a match against a single opponent.
+	K float64
+	// Initial is the rating of a player before their first match.
+	Initial float64
+}
+
+func NewElo() Elo {
+	return Elo{K: DefaultKFactor, Initial: DefaultRating}
+}
+
+// Rate replays matches in the order they were played.
+func (e Elo) Rate(matches []Match) Ratings {
+	ratings := Ratings{}
+
+	for _, match := range matches {
+		e.Update(ratings, match)
+	}
+
+	return ratings
+}
+
+// Update moves the ratings of everyone who played in match. Matches with
+// fewer than two known players don't affect anyone's rating.
+func (e Elo) Update(ratings Ratings, match Match) {
+	players, places := standings(match)
+	if len(players) < 2 {
+		return
+	}
+
+	before := make([]float64, len(players))
+	for i, player := range players {
+		before[i] = e.rating(ratings, player)
+	}
+
+	k := e.K / float64(len(players)-1)
+
+	for i, player := range players {
+		var delta float64
+
+		for j := range players {
+			if i == j {
+				continue
+			}
+
+			expected := 1 / (1 + math.Pow(10, (before[j]-before[i])/400))
+			delta += k * (score(places[i], places[j]) - expected)
+		}
+
+		ratings[player] = before[i] + delta
+	}
+}
+
+func (e Elo) rating(ratings Ratings, player string) float64 {
+	if rating, ok := ratings[player]; ok {
+		return rating
+	}
+
+	return e.Initial
+}
+
+// standings lists everyone known to have played match with the place they
+// finished in, 0 being first.
+func standings(match Match) ([]string, []int) {
+	var players []string
+	var places []int
+
+	add := func(player string, place int) {
+		if player != "" && !slices.Contains(players, player) {
+			players = append(players, player)
+			places = append(places, place)
+		}
+	}
+
+	add(match.Winner, 0)
+	for place, player := range match.FinishingOrder {
+		add(player, place)
+	}
+	for _, player := range match.Participants {
+		add(player, len(match.FinishingOrder))
+	}
+
+	return players, places
+}
+
+func score(place, opponentPlace int) float64 {
+	switch {
+	case place < opponentPlace:
+		return 1
+	case place > opponentPlace:
+		return 0
+	default:
+		return 0.5
+	}
+}
+
+// Rated returns a copy of the league with everyone's rating, ordered by
+// rating, highest first. Only players already in the league are rated, and
+// those without a rating get initial.
+func (l League) Rated(ratings Ratings, initial float64) League {
+	rated := slices.Clone(l)
+
+	for i := range rated {
+		rating, ok := ratings[rated[i].Name]
+		if !ok {
+			rating = initial
+		}
+		rated[i].Rating = math.Round(rating*100) / 100
+	}
+
+	sort.SliceStable(rated, func(i, j int) bool {
+		if rated[i].Rating != rated[j].Rating {
+			return rated[i].Rating > rated[j].Rating
+		}
+		return rated[i].Name < rated[j].Name
+	})
+
+	return rated
+}
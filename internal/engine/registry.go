@@ -0,0 +1,207 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPlayerNotFound = errors.New("player not found")
+	ErrNameTaken      = errors.New("name already refers to another player")
+)
+
+// RegisteredPlayer is a player known to a league, however their name is
+// spelled.
+type RegisteredPlayer struct {
+	// ID is the PlayerID of the name they were registered under.
+	ID string `json:"id"`
+	// Name is how they're recorded and shown.
+	Name string `json:"name"`
+	// Aliases are the other names they go by.
+	Aliases   []string  `json:"aliases,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Known reports whether name refers to the player.
+func (p RegisteredPlayer) Known(name string) bool {
+	id := PlayerID(name)
+
+	return id == p.ID || slices.ContainsFunc(p.Aliases, func(alias string) bool {
+		return PlayerID(alias) == id
+	})
+}
+
+// PlayerRegistry keeps the players of a league, so every spelling of their
+// name is recorded as the same player.
+type PlayerRegistry interface {
+	// Register returns the player name refers to, registering them under
+	// name if it refers to nobody yet.
+	Register(ctx context.Context, name string, at time.Time) (RegisteredPlayer, error)
+	// FindPlayer returns the player name refers to, or ErrPlayerNotFound.
+	FindPlayer(ctx context.Context, name string) (RegisteredPlayer, error)
+	ListPlayers(ctx context.Context) ([]RegisteredPlayer, error)
+	// AddAlias lets alias refer to the player name refers to.
+	AddAlias(ctx context.Context, name, alias string) (RegisteredPlayer, error)
+	// MergePlayers makes from refer to the player into refers to, along
+	// with every other name of the player from referred to.
+	MergePlayers(ctx context.Context, from, into string, at time.Time) (RegisteredPlayer, error)
+}
+
+// RegisteredLeagues are Leagues keeping a registry of the players of every
+// league.
+type RegisteredLeagues interface {
+	Players(ctx context.Context, id string) (PlayerRegistry, error)
+}
+
+// MergePlayers makes from refer to the player into refers to in registry,
+// and records the matches and wins of from in store under their name, if
+// store can be corrected. by names who merged them.
+func MergePlayers(ctx context.Context, registry PlayerRegistry, store PlayerStore, from, into, by string, at time.Time) (RegisteredPlayer, error) {
+	// matches are recorded under the name of whoever from refers to
+	recorded := from
+	if folded, err := registry.FindPlayer(ctx, from); err == nil {
+		recorded = folded.Name
+	} else if !errors.Is(err, ErrPlayerNotFound) {
+		return RegisteredPlayer{}, err
+	}
+
+	// the registry goes first, so merging again finishes a merge the store
+	// failed
+	player, err := registry.MergePlayers(ctx, from, into, at)
+	if err != nil {
+		return RegisteredPlayer{}, err
+	}
+
+	corrector, ok := store.(Corrector)
+	if !ok {
+		return player, nil
+	}
+
+	correction := Correction{Action: ActionMerge, Player: recorded, Into: player.Name, Reason: "merged " + NormalizeName(from) + " into " + player.Name}
+	if _, err := corrector.Correct(ctx, correction, by, at); err != nil {
+		return RegisteredPlayer{}, fmt.Errorf("couldn't merge the matches of %s: %w", from, err)
+	}
+
+	return player, nil
+}
+
+// NormalizeName trims name and collapses the spaces inside it.
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// PlayerID is the canonical ID of the player called name, the same however
+// name is spaced or capitalized.
+func PlayerID(name string) string {
+	return strings.ToLower(NormalizeName(name))
+}
+
+// Registry is every registered player of a league. Its methods return
+// copies, leaving it untouched so it stays current if a copy can't be saved.
+type Registry []RegisteredPlayer
+
+// Find returns the player name refers to, or nil.
+func (r Registry) Find(name string) *RegisteredPlayer {
+	i := slices.IndexFunc(r, func(p RegisteredPlayer) bool {
+		return p.Known(name)
+	})
+
+	if i < 0 {
+		return nil
+	}
+
+	return &r[i]
+}
+
+// Register returns the registry along with the player name refers to, who is
+// added to it under name if they aren't in it yet.
+func (r Registry) Register(name string, at time.Time) (Registry, RegisteredPlayer, error) {
+	name = NormalizeName(name)
+	if err := ValidatePlayerName(name); err != nil {
+		return nil, RegisteredPlayer{}, err
+	}
+
+	if player := r.Find(name); player != nil {
+		return r, *player, nil
+	}
+
+	player := RegisteredPlayer{ID: PlayerID(name), Name: name, CreatedAt: at}
+	return append(slices.Clone(r), player), player, nil
+}
+
+// AddAlias returns a copy of the registry where alias refers to the player
+// name refers to.
+func (r Registry) AddAlias(name, alias string) (Registry, RegisteredPlayer, error) {
+	alias = NormalizeName(alias)
+	if err := ValidatePlayerName(alias); err != nil {
+		return nil, RegisteredPlayer{}, err
+	}
+
+	player := r.Find(name)
+	if player == nil {
+		return nil, RegisteredPlayer{}, fmt.Errorf("%w: %s", ErrPlayerNotFound, name)
+	}
+
+	if other := r.Find(alias); other != nil {
+		if other.ID != player.ID {
+			return nil, RegisteredPlayer{}, fmt.Errorf("%w, %s is %s", ErrNameTaken, alias, other.Name)
+		}
+		return r, *player, nil
+	}
+
+	return r.replace(player.ID, func(p *RegisteredPlayer) {
+		p.Aliases = append(slices.Clone(p.Aliases), alias)
+	})
+}
+
+// Merge returns a copy of the registry where from, and every other name of
+// the player it referred to, refers to the player into refers to. Either of
+// them is registered first if they weren't, as names recorded before there
+// was a registry can still be merged.
+func (r Registry) Merge(from, into string, at time.Time) (Registry, RegisteredPlayer, error) {
+	from = NormalizeName(from)
+	if err := ValidatePlayerName(from); err != nil {
+		return nil, RegisteredPlayer{}, err
+	}
+
+	merged, player, err := r.Register(into, at)
+	if err != nil {
+		return nil, RegisteredPlayer{}, err
+	}
+
+	folded := merged.Find(from)
+	switch {
+	case folded == nil:
+		return merged.AddAlias(player.Name, from)
+	case folded.ID == player.ID:
+		return merged, player, nil
+	}
+
+	names := append([]string{folded.Name}, folded.Aliases...)
+	merged = slices.DeleteFunc(slices.Clone(merged), func(p RegisteredPlayer) bool {
+		return p.ID == folded.ID
+	})
+
+	return merged.replace(player.ID, func(p *RegisteredPlayer) {
+		p.Aliases = append(slices.Clone(p.Aliases), names...)
+	})
+}
+
+// replace returns a copy of the registry with the player with id changed by
+// change.
+func (r Registry) replace(id string, change func(*RegisteredPlayer)) (Registry, RegisteredPlayer, error) {
+	replaced := slices.Clone(r)
+
+	for i := range replaced {
+		if replaced[i].ID == id {
+			change(&replaced[i])
+			return replaced, replaced[i], nil
+		}
+	}
+
+	return nil, RegisteredPlayer{}, fmt.Errorf("%w: %s", ErrPlayerNotFound, id)
+}
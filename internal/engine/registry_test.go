@@ -0,0 +1,81 @@
+package engine_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestRegistry(t *testing.T) {
+	at := time.Date(2026, time.May, 9, 21, 0, 0, 0, time.UTC)
+
+	t.Run("it registers every spelling of a name as the same player", func(t *testing.T) {
+		registry, cleo, err := engine.Registry{}.Register("  Cleo   Patra ", at)
+		tests.AssertNoError(t, err)
+
+		for _, name := range []string{"cleo patra", "CLEO\tPATRA", "Cleo Patra"} {
+			registry, player, err := registry.Register(name, at)
+			tests.AssertNoError(t, err)
+			if len(registry) != 1 || player.ID != cleo.ID {
+				t.Errorf("got player %+v of %d for %q, want %+v", player, len(registry), name, cleo)
+			}
+		}
+		if cleo.Name != "Cleo Patra" || cleo.ID != "cleo patra" {
+			t.Errorf("got player %+v, want Cleo Patra", cleo)
+		}
+	})
+
+	t.Run("it refuses bad names", func(t *testing.T) {
+		if _, _, err := (engine.Registry{}).Register("   ", at); !errors.Is(err, engine.ErrBadPlayerName) {
+			t.Errorf("got error %v, want %v", err, engine.ErrBadPlayerName)
+		}
+	})
+
+	t.Run("it finds players by their aliases", func(t *testing.T) {
+		registry, _, err := engine.Registry{}.Register("Cleo", at)
+		tests.AssertNoError(t, err)
+		registry, _, err = registry.AddAlias("cleo", "Cleopatra")
+		tests.AssertNoError(t, err)
+
+		if player := registry.Find("cleopatra"); player == nil || player.Name != "Cleo" {
+			t.Errorf("got player %+v, want Cleo", player)
+		}
+	})
+
+	t.Run("it refuses aliases of other players", func(t *testing.T) {
+		registry, _, err := engine.Registry{}.Register("Cleo", at)
+		tests.AssertNoError(t, err)
+		registry, _, err = registry.Register("Chris", at)
+		tests.AssertNoError(t, err)
+
+		if _, _, err := registry.AddAlias("Cleo", "chris"); !errors.Is(err, engine.ErrNameTaken) {
+			t.Errorf("got error %v, want %v", err, engine.ErrNameTaken)
+		}
+		if _, _, err := registry.AddAlias("Floyd", "Pepper"); !errors.Is(err, engine.ErrPlayerNotFound) {
+			t.Errorf("got error %v, want %v", err, engine.ErrPlayerNotFound)
+		}
+	})
+
+	t.Run("it merges players with their aliases", func(t *testing.T) {
+		registry, _, err := engine.Registry{}.Register("Cleo", at)
+		tests.AssertNoError(t, err)
+		registry, _, err = registry.Register("Cleopatra", at)
+		tests.AssertNoError(t, err)
+		registry, _, err = registry.AddAlias("Cleopatra", "Queen")
+		tests.AssertNoError(t, err)
+
+		merged, player, err := registry.Merge("queen", "Cleo", at)
+		tests.AssertNoError(t, err)
+
+		if len(merged) != 1 || player.Name != "Cleo" || !slices.Equal(player.Aliases, []string{"Cleopatra", "Queen"}) {
+			t.Errorf("got player %+v of %d, want Cleo known as Cleopatra and Queen", player, len(merged))
+		}
+		if len(registry) != 2 {
+			t.Errorf("got %d players in the registry merged, want it left alone", len(registry))
+		}
+	})
+}
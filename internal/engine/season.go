@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+var (
+	ErrSeasonExists   = errors.New("season already exists")
+	ErrSeasonNotFound = errors.New("season not found")
+	ErrBadSeasonID    = errors.New("season ids are 1 to 64 letters, digits and dashes")
+)
+
+var seasonID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]{0,63}$`)
+
+// Season is a closed season with the league as it stood when it ended.
+type Season struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Standings League    `json:"standings"`
+}
+
+// Includes reports whether match was finished during the season.
+func (s Season) Includes(match Match) bool {
+	return match.FinishedAt.After(s.StartedAt) && !match.FinishedAt.After(s.EndedAt)
+}
+
+// CloseSeason archives league as season id ending at, following the seasons
+// closed before it. The first season starts just before firstPlayed, the
+// start of the earliest match, so that match is included in it.
+func CloseSeason(id string, closed []Season, firstPlayed time.Time, league League, at time.Time) (Season, error) {
+	if err := ValidateSeasonID(id); err != nil {
+		return Season{}, err
+	}
+
+	if FindSeason(closed, id) != nil {
+		return Season{}, fmt.Errorf("%w: %s", ErrSeasonExists, id)
+	}
+
+	startedAt := at
+	switch {
+	case len(closed) > 0:
+		startedAt = closed[len(closed)-1].EndedAt
+	case !firstPlayed.IsZero() && firstPlayed.Before(at):
+		startedAt = firstPlayed.Add(-time.Nanosecond)
+	}
+
+	return Season{
+		ID:        id,
+		StartedAt: startedAt,
+		EndedAt:   at,
+		Standings: league.Sorted(),
+	}, nil
+}
+
+// ValidateSeasonID makes sure id can name a season, and the path it's served
+// on.
+func ValidateSeasonID(id string) error {
+	if !seasonID.MatchString(id) {
+		return fmt.Errorf("%w, got %q", ErrBadSeasonID, id)
+	}
+
+	return nil
+}
+
+// FirstPlayed is when the earliest of matches started, or zero without any.
+func FirstPlayed(matches []Match) time.Time {
+	var first time.Time
+
+	for _, match := range matches {
+		if match.StartedAt.IsZero() {
+			continue
+		}
+		if first.IsZero() || match.StartedAt.Before(first) {
+			first = match.StartedAt
+		}
+	}
+
+	return first
+}
+
+func FindSeason(seasons []Season, id string) *Season {
+	i := slices.IndexFunc(seasons, func(s Season) bool {
+		return s.ID == id
+	})
+
+	if i < 0 {
+		return nil
+	}
+
+	return &seasons[i]
+}
+
+// QuarterID names the calendar quarter t falls in, like 2026-Q3.
+func QuarterID(t time.Time) string {
+	return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+}
+
+func quarterStart(t time.Time) time.Time {
+	month := time.Month((int(t.Month())-1)/3*3 + 1)
+	return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location())
+}
+
+// SeasonStore archives the league at the end of every season.
+type SeasonStore interface {
+	GetMatches(ctx context.Context) ([]Match, error)
+	GetSeasons(ctx context.Context) ([]Season, error)
+	CloseSeason(ctx context.Context, id string, at time.Time) (Season, error)
+}
+
+// CloseQuarter closes the season running in store once now is in a later
+// quarter than the one it started in, naming it after the quarter that ended
+// last. The season ends at now, as its standings count every win until then.
+// It reports whether a season was closed, so not when a season of that name
+// was already closed by hand.
+func CloseQuarter(ctx context.Context, store SeasonStore, now time.Time) (bool, error) {
+	seasons, err := store.GetSeasons(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var startedAt time.Time
+	if len(seasons) > 0 {
+		startedAt = seasons[len(seasons)-1].EndedAt
+	} else {
+		matches, err := store.GetMatches(ctx)
+		if err != nil {
+			return false, err
+		}
+		if len(matches) == 0 {
+			return false, nil
+		}
+		startedAt = matches[0].FinishedAt
+	}
+
+	quarter := quarterStart(now)
+	if !startedAt.Before(quarter) {
+		return false, nil
+	}
+
+	_, err = store.CloseSeason(ctx, QuarterID(quarter.AddDate(0, -3, 0)), now)
+	if errors.Is(err, ErrSeasonExists) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,78 @@
+package engine_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestCloseQuarter(t *testing.T) {
+	ctx := context.Background()
+	played := time.Date(2026, time.February, 12, 20, 0, 0, 0, time.UTC)
+
+	t.Run("it closes the season once the quarter is over", func(t *testing.T) {
+		store := &tests.StubPlayerStore{
+			Matches: []engine.Match{{ID: "1", StartedAt: played, FinishedAt: played, Winner: "Cleo"}},
+			League:  engine.League{{Name: "Cleo", Wins: 1}},
+		}
+
+		now := time.Date(2026, time.April, 3, 9, 30, 0, 0, time.UTC)
+		closed, err := engine.CloseQuarter(ctx, store, now)
+		tests.AssertNoError(t, err)
+
+		if !closed || len(store.Seasons) != 1 {
+			t.Fatalf("got seasons %+v, want one closed", store.Seasons)
+		}
+		season := store.Seasons[0]
+		if season.ID != "2026-Q1" || !season.EndedAt.Equal(now) {
+			t.Errorf("got season %s ended %v, want 2026-Q1 ended %v, when its standings were taken", season.ID, season.EndedAt, now)
+		}
+	})
+
+	t.Run("it carries on closing quarters after one was closed by hand", func(t *testing.T) {
+		store := &tests.StubPlayerStore{
+			Matches: []engine.Match{{ID: "1", StartedAt: played, FinishedAt: played, Winner: "Cleo"}},
+			Seasons: []engine.Season{{ID: "2026-Q1", StartedAt: played, EndedAt: played.Add(24 * time.Hour)}},
+		}
+
+		closed, err := engine.CloseQuarter(ctx, store, time.Date(2026, time.April, 3, 9, 30, 0, 0, time.UTC))
+		tests.AssertNoError(t, err)
+		if closed || len(store.Seasons) != 1 {
+			t.Fatalf("got seasons %+v, want only the one closed by hand", store.Seasons)
+		}
+
+		closed, err = engine.CloseQuarter(ctx, store, time.Date(2026, time.July, 2, 9, 30, 0, 0, time.UTC))
+		tests.AssertNoError(t, err)
+		if !closed || len(store.Seasons) != 2 || store.Seasons[1].ID != "2026-Q2" {
+			t.Errorf("got seasons %+v, want 2026-Q2 closed after the one closed by hand", store.Seasons)
+		}
+	})
+
+	t.Run("it names the season after the quarter that ended last", func(t *testing.T) {
+		store := &tests.StubPlayerStore{
+			Matches: []engine.Match{{ID: "1", StartedAt: played, FinishedAt: played, Winner: "Cleo"}},
+		}
+
+		closed, err := engine.CloseQuarter(ctx, store, time.Date(2027, time.January, 4, 9, 30, 0, 0, time.UTC))
+		tests.AssertNoError(t, err)
+		if !closed || len(store.Seasons) != 1 || store.Seasons[0].ID != "2026-Q4" {
+			t.Errorf("got seasons %+v, want 2026-Q4 closed", store.Seasons)
+		}
+	})
+
+	t.Run("it doesn't close the season before the quarter is over", func(t *testing.T) {
+		store := &tests.StubPlayerStore{
+			Matches: []engine.Match{{ID: "1", StartedAt: played, FinishedAt: played, Winner: "Cleo"}},
+		}
+
+		closed, err := engine.CloseQuarter(ctx, store, time.Date(2026, time.March, 31, 23, 0, 0, 0, time.UTC))
+		tests.AssertNoError(t, err)
+
+		if closed || len(store.Seasons) != 0 {
+			t.Errorf("got seasons %+v, want none", store.Seasons)
+		}
+	})
+}
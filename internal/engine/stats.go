@@ -0,0 +1,84 @@
+package engine
+
+import "time"
+
+// Stats sums up how a player has done in the running season of a league.
+type Stats struct {
+	Name string `json:"name"`
+	// Wins are those of the league, which can count wins adjusted or recorded
+	// without a match, while the rest only counts matches.
+	Wins        int `json:"wins"`
+	GamesPlayed int `json:"games_played"`
+	// WinPercentage is the share of the games played that were won, from 0
+	// to 100.
+	WinPercentage float64 `json:"win_percentage"`
+	// CurrentStreak is how many games in a row were won up to the last one.
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+	// AverageFieldSize is how many played the games played, counting the
+	// games of more than one known player.
+	AverageFieldSize float64   `json:"average_field_size"`
+	LastPlayed       time.Time `json:"last_played,omitzero"`
+	// RatingHistory is the rating after every game played, oldest first.
+	RatingHistory []RatingChange `json:"rating_history"`
+}
+
+// RatingChange is the rating of a player after a match.
+type RatingChange struct {
+	Match  string    `json:"match"`
+	At     time.Time `json:"at"`
+	Rating float64   `json:"rating"`
+}
+
+// PlayerStats sums up how name did in the matches finished after since, when
+// the running season started, with the wins the league credits them with.
+// matches are given in the order they were played, and all of them are
+// replayed with elo, as ratings carry over from one season to the next.
+func PlayerStats(name string, wins int, matches []Match, since time.Time, elo Elo) Stats {
+	stats := Stats{Name: name, Wins: wins, RatingHistory: []RatingChange{}}
+	ratings := Ratings{}
+
+	var won, fields, fieldTotal int
+	for _, match := range matches {
+		elo.Update(ratings, match)
+
+		if !match.FinishedAt.After(since) || !Played([]Match{match}, name) {
+			continue
+		}
+
+		stats.GamesPlayed++
+		if match.Winner == name {
+			won++
+			stats.CurrentStreak++
+			stats.LongestStreak = max(stats.LongestStreak, stats.CurrentStreak)
+		} else {
+			stats.CurrentStreak = 0
+		}
+
+		if field := fieldSize(match); field > 1 {
+			fields++
+			fieldTotal += field
+		}
+
+		if match.FinishedAt.After(stats.LastPlayed) {
+			stats.LastPlayed = match.FinishedAt
+		}
+
+		stats.RatingHistory = append(stats.RatingHistory, RatingChange{Match: match.ID, At: match.FinishedAt, Rating: elo.rating(ratings, name)})
+	}
+
+	if stats.GamesPlayed > 0 {
+		stats.WinPercentage = 100 * float64(won) / float64(stats.GamesPlayed)
+	}
+	if fields > 0 {
+		stats.AverageFieldSize = float64(fieldTotal) / float64(fields)
+	}
+
+	return stats
+}
+
+// fieldSize is how many played match, as far as it's known.
+func fieldSize(match Match) int {
+	players, _ := standings(match)
+	return max(match.Entrants, len(players))
+}
@@ -0,0 +1,66 @@
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+func TestPlayerStats(t *testing.T) {
+	at := time.Date(2026, time.June, 5, 20, 0, 0, 0, time.UTC)
+	matches := []engine.Match{
+		{ID: "1", FinishedAt: at, Entrants: 4, Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Cleo"}, Winner: "Cleo"},
+		{ID: "2", FinishedAt: at.Add(time.Hour), Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Cleo"}, Winner: "Cleo"},
+		{ID: "3", FinishedAt: at.Add(2 * time.Hour), Participants: []string{"Chris", "Ruth"}, FinishingOrder: []string{"Ruth"}, Winner: "Ruth"},
+		{ID: "4", FinishedAt: at.Add(3 * time.Hour), Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Chris"}, Winner: "Chris"},
+		engine.ManualWin("Cleo", at.Add(4*time.Hour)),
+	}
+
+	t.Run("it sums up the games played", func(t *testing.T) {
+		stats := engine.PlayerStats("Cleo", 3, matches, time.Time{}, engine.NewElo())
+
+		if stats.Name != "Cleo" || stats.Wins != 3 || stats.GamesPlayed != 4 || stats.WinPercentage != 75 {
+			t.Errorf("got stats %+v, want Cleo with 3 wins of 4 games", stats)
+		}
+		if stats.CurrentStreak != 1 || stats.LongestStreak != 2 {
+			t.Errorf("got streaks %d and %d, want 1 and 2", stats.CurrentStreak, stats.LongestStreak)
+		}
+		if stats.AverageFieldSize != 8.0/3 {
+			t.Errorf("got average field size %v, want %v", stats.AverageFieldSize, 8.0/3)
+		}
+		if !stats.LastPlayed.Equal(at.Add(4 * time.Hour)) {
+			t.Errorf("got last played %v, want %v", stats.LastPlayed, at.Add(4*time.Hour))
+		}
+	})
+
+	t.Run("it keeps the rating after every game played", func(t *testing.T) {
+		history := engine.PlayerStats("Chris", 1, matches, time.Time{}, engine.NewElo()).RatingHistory
+
+		if len(history) != 4 || history[0].Match != "1" || history[3].Match != "4" {
+			t.Fatalf("got rating history %+v, want matches 1 to 4", history)
+		}
+		if history[0].Rating >= engine.DefaultRating || history[3].Rating <= history[2].Rating {
+			t.Errorf("got rating history %+v, want it down after losing and up after winning", history)
+		}
+	})
+
+	t.Run("it sums up the games since the season started with the rating of all of them", func(t *testing.T) {
+		stats := engine.PlayerStats("Cleo", 0, matches, at.Add(time.Hour), engine.NewElo())
+
+		if stats.GamesPlayed != 2 || stats.WinPercentage != 50 || stats.LongestStreak != 1 {
+			t.Errorf("got stats %+v, want 1 win in the 2 games after the second", stats)
+		}
+		if len(stats.RatingHistory) != 2 || stats.RatingHistory[0].Match != "4" || stats.RatingHistory[0].Rating <= engine.DefaultRating {
+			t.Errorf("got rating history %+v, want match 4 rated after the ones before", stats.RatingHistory)
+		}
+	})
+
+	t.Run("it has nothing for players who never played", func(t *testing.T) {
+		stats := engine.PlayerStats("Floyd", 0, matches, time.Time{}, engine.NewElo())
+
+		if stats.GamesPlayed != 0 || stats.WinPercentage != 0 || len(stats.RatingHistory) != 0 || !stats.LastPlayed.IsZero() {
+			t.Errorf("got stats %+v, want none", stats)
+		}
+	})
+}
@@ -1,7 +1,26 @@
 package engine
 
+import (
+	"context"
+	"time"
+)
+
+// PlayerStore persists the matches played and the league derived from them.
+// Closing a season archives the league and starts it over.
 type PlayerStore interface {
-	GetPlayerScore(name string) int
-	RecordWin(name string)
-	GetLeague() League
+	GetPlayerScore(ctx context.Context, name string) (int, error)
+	RecordWin(ctx context.Context, name string) error
+	RecordMatch(ctx context.Context, match Match) error
+	GetMatches(ctx context.Context) ([]Match, error)
+	GetLeague(ctx context.Context) (League, error)
+	GetSeasons(ctx context.Context) ([]Season, error)
+	CloseSeason(ctx context.Context, id string, at time.Time) (Season, error)
+}
+
+// ChangeNotifier is a PlayerStore that tells when what it stores changes, so
+// the league can be followed without polling it.
+type ChangeNotifier interface {
+	// Subscribe returns a channel that gets a value after every change until
+	// cancel is called. Changes coming faster than they are read are merged.
+	Subscribe() (changes <-chan struct{}, cancel func())
 }
@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUserExists     = errors.New("user already exists")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrBadRole        = errors.New("roles are viewer, scorekeeper or admin")
+	ErrBadPassword    = errors.New("passwords are at least 8 characters")
+	ErrBadCredentials = errors.New("wrong name or password")
+)
+
+const (
+	minPasswordLength = 8
+	// passwordIterations is what OWASP recommends for PBKDF2 with SHA-256.
+	passwordIterations = 600_000
+)
+
+// Role is what a user, or a key, is allowed to do. Every role is allowed what
+// the ones before it are.
+type Role string
+
+const (
+	// RoleViewer reads the leagues.
+	RoleViewer Role = "viewer"
+	// RoleScorekeeper starts games and records their results.
+	RoleScorekeeper Role = "scorekeeper"
+	// RoleAdmin corrects scores and manages the seasons, leagues and players.
+	RoleAdmin Role = "admin"
+)
+
+// Roles are the roles, from the one allowed least.
+var Roles = []Role{RoleViewer, RoleScorekeeper, RoleAdmin}
+
+// ParseRole returns the role named name.
+func ParseRole(name string) (Role, error) {
+	if role := Role(name); slices.Contains(Roles, role) {
+		return role, nil
+	}
+
+	return "", fmt.Errorf("%w, got %q", ErrBadRole, name)
+}
+
+// Allows reports whether r is allowed what role is.
+func (r Role) Allows(role Role) bool {
+	return slices.Index(Roles, r) >= slices.Index(Roles, role)
+}
+
+// User logs in with a password to do what their role allows.
+type User struct {
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+	// PasswordHash is the password run through PBKDF2, as written by
+	// HashPassword.
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UserStore keeps the users of the server.
+type UserStore interface {
+	AddUser(ctx context.Context, user User) error
+	// GetUser returns user name, or ErrUserNotFound.
+	GetUser(ctx context.Context, name string) (User, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	RemoveUser(ctx context.Context, name string) error
+}
+
+// NewUser makes user name with role, logging in with password.
+func NewUser(name string, role Role, password string, at time.Time) (User, error) {
+	if err := ValidatePlayerName(name); err != nil {
+		return User{}, err
+	}
+
+	if _, err := ParseRole(string(role)); err != nil {
+		return User{}, err
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	return User{Name: name, Role: role, PasswordHash: hash, CreatedAt: at}, nil
+}
+
+// HashPassword salts and stretches password, so a leaked hash is slow to
+// guess from.
+func HashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", ErrBadPassword
+	}
+
+	salt := make([]byte, 16)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(salt)
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, sha256.Size)
+	if err != nil {
+		return "", fmt.Errorf("couldn't hash the password: %w", err)
+	}
+
+	return fmt.Sprintf("pbkdf2-sha256$%d$%x$%x", passwordIterations, salt, key), nil
+}
+
+// CheckPassword reports whether password is the one of user.
+func (u User) CheckPassword(password string) bool {
+	parts := strings.Split(u.PasswordHash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	salt, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	return err == nil && subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// Login returns user name of users if password is theirs, or
+// ErrBadCredentials.
+func Login(ctx context.Context, users UserStore, name, password string) (User, error) {
+	user, err := users.GetUser(ctx, name)
+	if errors.Is(err, ErrUserNotFound) {
+		return User{}, ErrBadCredentials
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	if !user.CheckPassword(password) {
+		return User{}, ErrBadCredentials
+	}
+
+	return user, nil
+}
@@ -0,0 +1,63 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestRoles(t *testing.T) {
+	cases := []struct {
+		role, wants engine.Role
+		allowed     bool
+	}{
+		{engine.RoleViewer, engine.RoleViewer, true},
+		{engine.RoleViewer, engine.RoleScorekeeper, false},
+		{engine.RoleScorekeeper, engine.RoleViewer, true},
+		{engine.RoleScorekeeper, engine.RoleAdmin, false},
+		{engine.RoleAdmin, engine.RoleScorekeeper, true},
+		{"", engine.RoleViewer, false},
+	}
+
+	for _, c := range cases {
+		if got := c.role.Allows(c.wants); got != c.allowed {
+			t.Errorf("got %v for %q allowing %q, want %v", got, c.role, c.wants, c.allowed)
+		}
+	}
+
+	if _, err := engine.ParseRole("owner"); !errors.Is(err, engine.ErrBadRole) {
+		t.Errorf("got error %v for owner, want %v", err, engine.ErrBadRole)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	users := tests.NewStubUserStore(t)
+
+	t.Run("it logs users in with their password", func(t *testing.T) {
+		user, err := engine.Login(ctx, users, "scorekeeper", "password")
+		tests.AssertNoError(t, err)
+
+		if user.Role != engine.RoleScorekeeper || user.PasswordHash == "password" {
+			t.Errorf("got user %+v, want the scorekeeper with a hashed password", user)
+		}
+	})
+
+	t.Run("it refuses wrong passwords and unknown users alike", func(t *testing.T) {
+		for _, credentials := range [][2]string{{"scorekeeper", "Password"}, {"Pepper", "password"}} {
+			if _, err := engine.Login(ctx, users, credentials[0], credentials[1]); !errors.Is(err, engine.ErrBadCredentials) {
+				t.Errorf("got error %v for %v, want %v", err, credentials, engine.ErrBadCredentials)
+			}
+		}
+	})
+
+	t.Run("it refuses short passwords", func(t *testing.T) {
+		if _, err := engine.NewUser("Pepper", engine.RoleAdmin, "short", time.Now()); !errors.Is(err, engine.ErrBadPassword) {
+			t.Errorf("got error %v, want %v", err, engine.ErrBadPassword)
+		}
+	})
+}
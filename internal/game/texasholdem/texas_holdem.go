@@ -1,35 +1,147 @@
 package texasholdem
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/oblassov/game-score-server/internal/engine"
 )
 
+// GameType identifies Texas Hold'em in recorded matches.
+const GameType = "texas-holdem"
+
 type TexasHoldem struct {
 	store   engine.PlayerStore
 	alerter engine.BlindAlerter
+	clock   engine.Clock
+
+	lock         sync.Mutex
+	startedAt    time.Time
+	entrants     int
+	participants []string
+	blinds       engine.BlindSchedule
+}
+
+// Option configures the optional parts of a TexasHoldem.
+type Option func(*TexasHoldem)
+
+// WithClock times games with clock instead of the system clock. The alerter
+// should be timed with the same clock.
+func WithClock(clock engine.Clock) Option {
+	return func(p *TexasHoldem) {
+		p.clock = clock
+	}
 }
 
-func NewTexasHoldem(store engine.PlayerStore, alerter engine.BlindAlerter) *TexasHoldem {
-	return &TexasHoldem{
+func NewTexasHoldem(store engine.PlayerStore, alerter engine.BlindAlerter, opts ...Option) *TexasHoldem {
+	p := &TexasHoldem{
 		store:   store,
 		alerter: alerter,
+		clock:   engine.SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
 	}
+
+	return p
 }
 
-func (p *TexasHoldem) Start(numberOfPlayers int, alertsDestination io.Writer) {
-	blindIncrement := time.Duration(5+numberOfPlayers) * time.Minute
-	blinds := []int{100, 200, 300, 400, 500, 600, 800, 1000, 2000, 4000, 8000}
+// Start schedules an alert for every level of blinds, each one when the
+// level before it is over. The blinds of a game started before are cancelled.
+func (p *TexasHoldem) Start(numberOfPlayers int, participants []string, blinds engine.BlindStructure, alertsDestination io.Writer) {
+	var alerts []engine.BlindAlert
 	blindTime := 0 * time.Minute
 
-	for _, blind := range blinds {
-		p.alerter.ScheduleAlertAt(blindTime, blind, alertsDestination)
-		blindTime += blindIncrement
+	for i, level := range blinds.Levels {
+		alert := engine.BlindAlert{At: blindTime, Level: level}
+		if i < len(blinds.Levels)-1 {
+			alert.Lasts = blinds.Duration(level, numberOfPlayers)
+		}
+
+		alerts = append(alerts, alert)
+		blindTime += blinds.Duration(level, numberOfPlayers)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.stopBlinds()
+	p.startedAt = p.clock.Now().UTC()
+	p.entrants = numberOfPlayers
+	p.participants = slices.Clone(participants)
+	p.blinds = p.alerter.ScheduleAlerts(alerts, alertsDestination)
+}
+
+// Blinds controls the blinds of the game started last, nil before the first
+// game.
+func (p *TexasHoldem) Blinds() engine.BlindSchedule {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.blinds
+}
+
+func (p *TexasHoldem) Stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.stopBlinds()
+}
+
+func (p *TexasHoldem) stopBlinds() {
+	if p.blinds != nil {
+		p.blinds.Cancel()
+		p.blinds = nil
 	}
 }
 
-func (p *TexasHoldem) Finish(winner string) {
-	p.store.RecordWin(winner)
+// Finish records the match started last with winner finishing first, then
+// stops its blinds. The blinds keep going for winners who didn't play and
+// when the match couldn't be recorded, so finishing can be tried again.
+func (p *TexasHoldem) Finish(ctx context.Context, winner string, confirmed bool) error {
+	p.lock.Lock()
+	participants := p.participants
+	seated, err := engine.SeatedWinner(participants, winner)
+	switch {
+	case errors.Is(err, engine.ErrNotParticipant) && confirmed:
+		participants = append(slices.Clone(participants), winner)
+	case err != nil:
+		p.lock.Unlock()
+		return err
+	default:
+		winner = seated
+	}
+
+	blinds := p.blinds
+	match := engine.Match{
+		ID:             engine.NewMatchID(),
+		Game:           GameType,
+		StartedAt:      p.startedAt,
+		FinishedAt:     p.clock.Now().UTC(),
+		Entrants:       max(p.entrants, len(participants)),
+		Participants:   participants,
+		FinishingOrder: []string{winner},
+		Winner:         winner,
+	}
+	p.lock.Unlock()
+
+	if err := p.store.RecordMatch(ctx, match); err != nil {
+		return fmt.Errorf("couldn't record the win of %s: %w", winner, err)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// another game may have started meanwhile
+	if p.blinds == blinds {
+		p.stopBlinds()
+	}
+
+	return nil
 }
@@ -1,11 +1,17 @@
 package texasholdem_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/oblassov/game-score-server/internal/engine"
 	"github.com/oblassov/game-score-server/internal/game/texasholdem"
 	"github.com/oblassov/game-score-server/tests"
 )
@@ -15,20 +21,20 @@ func TestGame_Start(t *testing.T) {
 		blindAlerter := &tests.SpyBlindAlerter{}
 		game := texasholdem.NewTexasHoldem(tests.DummyPlayerStore, blindAlerter)
 
-		game.Start(5, io.Discard)
+		game.Start(5, nil, engine.StandardBlinds(), io.Discard)
 
 		cases := []tests.ScheduledAlert{
-			{At: 0 * time.Minute, Amount: 100},
-			{At: 10 * time.Minute, Amount: 200},
-			{At: 20 * time.Minute, Amount: 300},
-			{At: 30 * time.Minute, Amount: 400},
-			{At: 40 * time.Minute, Amount: 500},
-			{At: 50 * time.Minute, Amount: 600},
-			{At: 60 * time.Minute, Amount: 800},
-			{At: 70 * time.Minute, Amount: 1000},
-			{At: 80 * time.Minute, Amount: 2000},
-			{At: 90 * time.Minute, Amount: 4000},
-			{At: 100 * time.Minute, Amount: 8000},
+			{At: 0 * time.Minute, Level: blinds(100), Lasts: 10 * time.Minute},
+			{At: 10 * time.Minute, Level: blinds(200), Lasts: 10 * time.Minute},
+			{At: 20 * time.Minute, Level: blinds(300), Lasts: 10 * time.Minute},
+			{At: 30 * time.Minute, Level: blinds(400), Lasts: 10 * time.Minute},
+			{At: 40 * time.Minute, Level: blinds(500), Lasts: 10 * time.Minute},
+			{At: 50 * time.Minute, Level: blinds(600), Lasts: 10 * time.Minute},
+			{At: 60 * time.Minute, Level: blinds(800), Lasts: 10 * time.Minute},
+			{At: 70 * time.Minute, Level: blinds(1000), Lasts: 10 * time.Minute},
+			{At: 80 * time.Minute, Level: blinds(2000), Lasts: 10 * time.Minute},
+			{At: 90 * time.Minute, Level: blinds(4000), Lasts: 10 * time.Minute},
+			{At: 100 * time.Minute, Level: blinds(8000)},
 		}
 
 		checkSchedulingCases(cases, t, *blindAlerter)
@@ -38,28 +44,215 @@ func TestGame_Start(t *testing.T) {
 		blindAlerter := &tests.SpyBlindAlerter{}
 		game := texasholdem.NewTexasHoldem(tests.DummyPlayerStore, blindAlerter)
 
-		game.Start(7, io.Discard)
+		game.Start(7, nil, engine.StandardBlinds(), io.Discard)
 
 		cases := []tests.ScheduledAlert{
-			{At: 0 * time.Minute, Amount: 100},
-			{At: 12 * time.Minute, Amount: 200},
-			{At: 24 * time.Minute, Amount: 300},
-			{At: 36 * time.Minute, Amount: 400},
+			{At: 0 * time.Minute, Level: blinds(100), Lasts: 12 * time.Minute},
+			{At: 12 * time.Minute, Level: blinds(200), Lasts: 12 * time.Minute},
+			{At: 24 * time.Minute, Level: blinds(300), Lasts: 12 * time.Minute},
+			{At: 36 * time.Minute, Level: blinds(400), Lasts: 12 * time.Minute},
 		}
 
 		checkSchedulingCases(cases, t, *blindAlerter)
 	})
 }
 
+func TestGame_StartWithBlindStructure(t *testing.T) {
+	blindAlerter := &tests.SpyBlindAlerter{}
+	game := texasholdem.NewTexasHoldem(tests.DummyPlayerStore, blindAlerter)
+
+	game.Start(6, nil, engine.BlindStructure{
+		Name:          "turbo",
+		LevelDuration: engine.Duration(3 * time.Minute),
+		Levels: []engine.BlindLevel{
+			{SmallBlind: 25, BigBlind: 50},
+			{SmallBlind: 50, BigBlind: 100, Ante: 10},
+			{Break: true, Duration: engine.Duration(10 * time.Minute)},
+			{SmallBlind: 100, BigBlind: 200, Ante: 25},
+		},
+	}, io.Discard)
+
+	cases := []tests.ScheduledAlert{
+		{At: 0 * time.Minute, Level: engine.BlindLevel{SmallBlind: 25, BigBlind: 50}, Lasts: 3 * time.Minute},
+		{At: 3 * time.Minute, Level: engine.BlindLevel{SmallBlind: 50, BigBlind: 100, Ante: 10}, Lasts: 3 * time.Minute},
+		{At: 6 * time.Minute, Level: engine.BlindLevel{Break: true, Duration: engine.Duration(10 * time.Minute)}, Lasts: 10 * time.Minute},
+		{At: 16 * time.Minute, Level: engine.BlindLevel{SmallBlind: 100, BigBlind: 200, Ante: 25}},
+	}
+
+	checkSchedulingCases(cases, t, *blindAlerter)
+}
+
 func TestGame_Finish(t *testing.T) {
 	playerStore := &tests.StubPlayerStore{}
 	game := texasholdem.NewTexasHoldem(playerStore, tests.DummyBlindAlerter)
 	winner := "Ruth"
 
-	game.Finish(winner)
+	tests.AssertNoError(t, game.Finish(context.Background(), winner, false))
 	tests.AssertPlayerWin(t, playerStore, winner)
 }
 
+func TestGame_FinishRecordsMatch(t *testing.T) {
+	playerStore := &tests.StubPlayerStore{}
+	game := texasholdem.NewTexasHoldem(playerStore, tests.DummyBlindAlerter)
+
+	game.Start(4, nil, engine.StandardBlinds(), io.Discard)
+	tests.AssertNoError(t, game.Finish(context.Background(), "Ruth", false))
+
+	if len(playerStore.Matches) != 1 {
+		t.Fatalf("got %d matches recorded, want 1", len(playerStore.Matches))
+	}
+
+	match := playerStore.Matches[0]
+	if match.Game != texasholdem.GameType || match.Entrants != 4 || match.Winner != "Ruth" {
+		t.Errorf("got match %+v, want a %s match of 4 won by Ruth", match, texasholdem.GameType)
+	}
+
+	if match.ID == "" || match.FinishedAt.Before(match.StartedAt) {
+		t.Errorf("got match %+v, want an ID and a finish after the start", match)
+	}
+}
+
+func TestGame_FinishChecksParticipants(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it records the participants with the winner as they're named", func(t *testing.T) {
+		playerStore := &tests.StubPlayerStore{}
+		game := texasholdem.NewTexasHoldem(playerStore, tests.DummyBlindAlerter)
+
+		game.Start(3, []string{"Ruth", "Cleo", "Chris"}, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, game.Finish(ctx, "cleo", false))
+
+		match := playerStore.Matches[0]
+		if match.Winner != "Cleo" || !slices.Equal(match.Participants, []string{"Ruth", "Cleo", "Chris"}) {
+			t.Errorf("got match %+v, want Cleo winning among Ruth, Cleo and Chris", match)
+		}
+	})
+
+	t.Run("it refuses winners who didn't play and keeps the blinds going", func(t *testing.T) {
+		playerStore := &tests.StubPlayerStore{}
+		game := texasholdem.NewTexasHoldem(playerStore, tests.DummyBlindAlerter)
+
+		game.Start(2, []string{"Ruth", "Cleo"}, engine.StandardBlinds(), io.Discard)
+		if err := game.Finish(ctx, "Floyd", false); !errors.Is(err, engine.ErrNotParticipant) {
+			t.Errorf("got error %v, want %v", err, engine.ErrNotParticipant)
+		}
+
+		if len(playerStore.Matches) != 0 || game.Blinds() == nil {
+			t.Errorf("got matches %+v, want none recorded and the game still going", playerStore.Matches)
+		}
+	})
+
+	t.Run("it records confirmed winners as having played", func(t *testing.T) {
+		playerStore := &tests.StubPlayerStore{}
+		game := texasholdem.NewTexasHoldem(playerStore, tests.DummyBlindAlerter)
+
+		game.Start(2, []string{"Ruth", "Cleo"}, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, game.Finish(ctx, "Floyd", true))
+
+		match := playerStore.Matches[0]
+		if match.Winner != "Floyd" || match.Entrants != 3 || !slices.Equal(match.Participants, []string{"Ruth", "Cleo", "Floyd"}) {
+			t.Errorf("got match %+v, want Floyd winning among 3", match)
+		}
+	})
+}
+
+func TestGame_Tournament(t *testing.T) {
+	start := time.Date(2026, time.March, 14, 19, 0, 0, 0, time.UTC)
+	clock := tests.NewManualClock(start)
+	store := &tests.StubPlayerStore{}
+	out := &bytes.Buffer{}
+	game := texasholdem.NewTexasHoldem(store, engine.NewAlerter(clock), texasholdem.WithClock(clock))
+
+	game.Start(5, nil, engine.StandardBlinds(), out)
+	for range 4 * 60 {
+		clock.Advance(time.Minute)
+	}
+	tests.AssertNoError(t, game.Finish(context.Background(), "Ruth", false))
+
+	alerts := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(alerts) != 11 || alerts[10] != "Blind is now 8000" {
+		t.Errorf("got alerts %q, want all 11 levels up to 8000", alerts)
+	}
+
+	match := store.Matches[0]
+	if !match.StartedAt.Equal(start) || !match.FinishedAt.Equal(start.Add(4*time.Hour)) {
+		t.Errorf("got a match from %v to %v, want four hours from %v", match.StartedAt, match.FinishedAt, start)
+	}
+}
+
+func TestGame_Blinds(t *testing.T) {
+	t.Run("finishing the game cancels its blinds", func(t *testing.T) {
+		blindAlerter := &tests.SpyBlindAlerter{}
+		game := texasholdem.NewTexasHoldem(&tests.StubPlayerStore{}, blindAlerter)
+
+		game.Start(5, nil, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, game.Finish(context.Background(), "Ruth", false))
+
+		assertBlindsCancelled(t, blindAlerter, 0)
+		if game.Blinds() != nil {
+			t.Error("the finished game still has blinds")
+		}
+	})
+
+	t.Run("stopping the game cancels its blinds without recording it", func(t *testing.T) {
+		store := &tests.StubPlayerStore{}
+		blindAlerter := &tests.SpyBlindAlerter{}
+		game := texasholdem.NewTexasHoldem(store, blindAlerter)
+
+		game.Start(5, nil, engine.StandardBlinds(), io.Discard)
+		game.Stop()
+
+		assertBlindsCancelled(t, blindAlerter, 0)
+		if len(store.Matches) != 0 {
+			t.Errorf("got %d matches recorded, want none", len(store.Matches))
+		}
+	})
+
+	t.Run("starting another game cancels the blinds of the last one", func(t *testing.T) {
+		blindAlerter := &tests.SpyBlindAlerter{}
+		game := texasholdem.NewTexasHoldem(tests.DummyPlayerStore, blindAlerter)
+
+		game.Start(5, nil, engine.StandardBlinds(), io.Discard)
+		game.Start(3, nil, engine.StandardBlinds(), io.Discard)
+
+		assertBlindsCancelled(t, blindAlerter, 0)
+		if blindAlerter.Schedules[1].Cancelled {
+			t.Error("the blinds of the running game were cancelled")
+		}
+	})
+}
+
+func TestGame_FinishStoreFailure(t *testing.T) {
+	playerStore := &tests.StubPlayerStore{Err: errors.New("disk full")}
+	blindAlerter := &tests.SpyBlindAlerter{}
+	game := texasholdem.NewTexasHoldem(playerStore, blindAlerter)
+
+	game.Start(5, nil, engine.StandardBlinds(), io.Discard)
+	if err := game.Finish(context.Background(), "Ruth", false); err == nil {
+		t.Error("expected an error but didn't get one")
+	}
+
+	if blindAlerter.Schedules[0].Cancelled || game.Blinds() == nil {
+		t.Error("the blinds of a game that couldn't be recorded were stopped")
+	}
+}
+
+func assertBlindsCancelled(t testing.TB, blindAlerter *tests.SpyBlindAlerter, game int) {
+	t.Helper()
+
+	if len(blindAlerter.Schedules) <= game {
+		t.Fatalf("blinds of game %d were never scheduled", game)
+	}
+
+	if !blindAlerter.Schedules[game].Cancelled {
+		t.Errorf("the blinds of game %d weren't cancelled", game)
+	}
+}
+
+func blinds(smallBlind int) engine.BlindLevel {
+	return engine.BlindLevel{SmallBlind: smallBlind}
+}
+
 func checkSchedulingCases(cases []tests.ScheduledAlert, t *testing.T, blindAlerter tests.SpyBlindAlerter) {
 	t.Helper()
 
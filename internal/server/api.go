@@ -0,0 +1,366 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// APIPrefix is where version 2 of the API is served, as described by
+// openapi.json.
+const APIPrefix = "/api/v2"
+
+// maxBodySize is the most bytes read from the body of a request.
+const maxBodySize = 1 << 20
+
+//go:embed openapi.json
+var openAPI []byte
+
+// APIPlayer is a player as the API describes them.
+type APIPlayer struct {
+	Name string `json:"name"`
+	Wins int    `json:"wins"`
+	// Rating is only filled in for players ranked by rating.
+	Rating float64 `json:"rating,omitempty"`
+}
+
+// APISeason is a past season as the API describes it.
+type APISeason struct {
+	ID        string      `json:"id"`
+	StartedAt time.Time   `json:"started_at"`
+	EndedAt   time.Time   `json:"ended_at"`
+	Standings []APIPlayer `json:"standings"`
+}
+
+// APILeague is a named league as the API describes it.
+type APILeague struct {
+	ID string `json:"id"`
+}
+
+// handleAPI routes version 2 of the API, every resource answered with JSON.
+func (p *PlayerServer) handleAPI() http.Handler {
+	router := http.NewServeMux()
+
+	router.Handle("/openapi.json", http.HandlerFunc(p.openAPIHandler))
+	p.handleAPILeague(router)
+	router.Handle("/leagues", http.HandlerFunc(p.apiLeagues))
+	router.Handle("/leagues/{league}", http.HandlerFunc(p.apiLeague))
+	router.Handle("/leagues/{league}/", p.inLeague(p.handleAPILeague(http.NewServeMux())))
+	router.Handle("/games", http.HandlerFunc(p.gamesHandler))
+	router.Handle("/games/{id}", http.HandlerFunc(p.gameHandler))
+	router.Handle("/games/{id}/{action}", http.HandlerFunc(p.changeGame))
+	router.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusNotFound, fmt.Sprintf("there's nothing at %s%s", APIPrefix, r.URL.Path))
+	}))
+
+	return http.StripPrefix(APIPrefix, p.guard(router))
+}
+
+// handleAPILeague routes the resources of a single league, like
+// handleLeague does for the first version.
+func (p *PlayerServer) handleAPILeague(router *http.ServeMux) *http.ServeMux {
+	router.Handle("/players", http.HandlerFunc(p.apiPlayers))
+	router.Handle("/players/{name}", http.HandlerFunc(p.apiPlayer))
+	router.Handle("/players/{name}/wins", http.HandlerFunc(p.apiWins))
+	router.Handle("/players/{name}/aliases", http.HandlerFunc(p.aliasesHandler))
+	router.Handle("/players/{name}/merge", http.HandlerFunc(p.mergeHandler))
+	router.Handle("/players/{name}/stats", http.HandlerFunc(p.statsHandler))
+	router.Handle("/matches", http.HandlerFunc(p.apiMatches))
+	router.Handle("/matches/{match}", http.HandlerFunc(p.apiMatch))
+	router.Handle("/seasons", http.HandlerFunc(p.apiSeasons))
+	router.Handle("/seasons/{season}", http.HandlerFunc(p.apiSeason))
+	router.Handle("/corrections", http.HandlerFunc(p.correctionsHandler))
+	router.Handle("/audit", http.HandlerFunc(p.auditHandler))
+
+	return router
+}
+
+func (p *PlayerServer) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "the OpenAPI document can only be read with GET", http.MethodGet) {
+		return
+	}
+
+	w.Header().Set("content-type", JSONContentType)
+	if _, err := w.Write(openAPI); err != nil {
+		log.Println("couldn't write the OpenAPI document: ", err)
+	}
+}
+
+// apiPlayers lists the players of the league, ordered like /league.
+func (p *PlayerServer) apiPlayers(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "players can only be listed with GET", http.MethodGet) {
+		return
+	}
+
+	league, err := p.rankedLeague(r.Context(), r.URL.Query().Get("season"), r.URL.Query().Get("sort"))
+	if err != nil {
+		writeLeagueError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiPlayers(league))
+}
+
+func (p *PlayerServer) apiPlayer(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "players can only be checked with GET", http.MethodGet) {
+		return
+	}
+
+	name, ok := playerName(w, r)
+	if !ok {
+		return
+	}
+
+	name, err := p.lookUp(r.Context(), name)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	wins, err := p.score(r.Context(), name)
+	if err != nil {
+		writeScoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APIPlayer{Name: name, Wins: wins})
+}
+
+// apiWins credits the player with a win, answering with the player.
+func (p *PlayerServer) apiWins(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "wins can only be recorded with POST", http.MethodPost) {
+		return
+	}
+
+	name, ok := playerName(w, r)
+	if !ok {
+		return
+	}
+
+	name, err := p.register(r.Context(), name)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	if err := p.storeFor(r.Context()).RecordWin(r.Context(), name); err != nil {
+		log.Println("couldn't record the win: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't record the win")
+		return
+	}
+
+	wins, err := p.score(r.Context(), name)
+	if err != nil {
+		writeScoreError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, APIPlayer{Name: name, Wins: wins})
+}
+
+func (p *PlayerServer) apiMatches(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "matches can only be listed with GET", http.MethodGet) {
+		return
+	}
+
+	matches, err := p.storeFor(r.Context()).GetMatches(r.Context())
+	if err != nil {
+		log.Println("couldn't get the matches: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't get the matches")
+		return
+	}
+
+	if matches == nil {
+		matches = []engine.Match{}
+	}
+
+	writeJSON(w, http.StatusOK, matches)
+}
+
+func (p *PlayerServer) apiMatch(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "matches can only be checked with GET", http.MethodGet) {
+		return
+	}
+
+	matches, err := p.storeFor(r.Context()).GetMatches(r.Context())
+	if err != nil {
+		log.Println("couldn't get the matches: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't get the match")
+		return
+	}
+
+	id := r.PathValue("match")
+	for _, match := range matches {
+		if match.ID == id {
+			writeJSON(w, http.StatusOK, match)
+			return
+		}
+	}
+
+	writeProblem(w, http.StatusNotFound, fmt.Sprintf("no match %s", id))
+}
+
+// apiSeason returns a past season on GET and closes the current one under
+// its name on POST.
+func (p *PlayerServer) apiSeason(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "seasons can only be checked with GET or closed with POST", http.MethodGet, http.MethodPost) {
+		return
+	}
+
+	id := r.PathValue("season")
+
+	if r.Method == http.MethodPost {
+		season, err := p.storeFor(r.Context()).CloseSeason(r.Context(), id, time.Now().UTC())
+		if err != nil {
+			writeSeasonError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, apiSeason(season))
+		return
+	}
+
+	_, season, err := p.standings(r.Context(), id)
+	if err != nil {
+		writeLeagueError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, apiSeason(*season))
+}
+
+func (p *PlayerServer) apiSeasons(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "seasons can only be listed with GET", http.MethodGet) {
+		return
+	}
+
+	seasons, err := p.storeFor(r.Context()).GetSeasons(r.Context())
+	if err != nil {
+		log.Println("couldn't get the seasons: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't get the seasons")
+		return
+	}
+
+	described := make([]APISeason, 0, len(seasons))
+	for _, season := range seasons {
+		described = append(described, apiSeason(season))
+	}
+
+	writeJSON(w, http.StatusOK, described)
+}
+
+// apiLeagues lists the named leagues on GET and starts a new one on POST.
+func (p *PlayerServer) apiLeagues(w http.ResponseWriter, r *http.Request) {
+	if p.leagues == nil {
+		writeProblem(w, http.StatusNotFound, noLeagues)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		ids, err := p.leagues.ListLeagues(r.Context())
+		if err != nil {
+			log.Println("couldn't list the leagues: ", err)
+			writeProblem(w, http.StatusInternalServerError, "couldn't list the leagues")
+			return
+		}
+
+		leagues := make([]APILeague, 0, len(ids))
+		for _, id := range ids {
+			leagues = append(leagues, APILeague{ID: id})
+		}
+
+		writeJSON(w, http.StatusOK, leagues)
+
+	case http.MethodPost:
+		var league APILeague
+		if err := decodeJSON(r, &league); err != nil {
+			writeProblem(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := p.leagues.CreateLeague(r.Context(), league.ID); err != nil {
+			writeCreateLeagueError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, league)
+
+	default:
+		methodNotAllowed(w, "leagues can only be listed with GET or created with POST", http.MethodGet, http.MethodPost)
+	}
+}
+
+func (p *PlayerServer) apiLeague(w http.ResponseWriter, r *http.Request) {
+	if p.leagues == nil {
+		writeProblem(w, http.StatusNotFound, noLeagues)
+		return
+	}
+
+	if !allowMethods(w, r, "leagues can only be checked with GET", http.MethodGet) {
+		return
+	}
+
+	id := r.PathValue("league")
+	_, err := p.leagues.Store(r.Context(), id)
+	if errors.Is(err, engine.ErrLeagueNotFound) {
+		writeProblem(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		log.Println("couldn't open the league: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't open the league")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, APILeague{ID: id})
+}
+
+func apiPlayers(league engine.League) []APIPlayer {
+	players := make([]APIPlayer, 0, len(league))
+	for _, player := range league {
+		players = append(players, APIPlayer{Name: player.Name, Wins: player.Wins, Rating: player.Rating})
+	}
+
+	return players
+}
+
+func apiSeason(season engine.Season) APISeason {
+	return APISeason{
+		ID:        season.ID,
+		StartedAt: season.StartedAt,
+		EndedAt:   season.EndedAt,
+		Standings: apiPlayers(season.Standings),
+	}
+}
+
+// playerName returns the valid player name of the path, answering with a 400
+// problem if it isn't.
+func playerName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("name")
+
+	if err := engine.ValidatePlayerName(name); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+
+	return name, true
+}
+
+// decodeJSON decodes the body of r into v, refusing fields it doesn't know.
+func decodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("couldn't decode the body: %w", err)
+	}
+
+	return nil
+}
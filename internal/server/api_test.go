@@ -0,0 +1,322 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/server"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+// TestAPIContract calls every operation of the OpenAPI document and checks the
+// server answers as it says it does.
+func TestAPIContract(t *testing.T) {
+	playerServer, params := newAPIServer(t)
+	spec := getOpenAPI(t, playerServer)
+
+	// bodies are the requests sent to operations taking one
+	bodies := map[string]string{
+		"POST /leagues":                `{"id": "friday"}`,
+		"POST /corrections":            `{"action": "adjust", "player": "Pepper", "wins": -1, "reason": "counted twice"}`,
+		"POST /players/{name}/aliases": `{"alias": "Pep"}`,
+		"POST /players/{name}/merge":   `{"into": "Floyd"}`,
+	}
+
+	paths := spec["paths"].(map[string]any)
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		item := paths[path].(map[string]any)
+
+		for _, method := range []string{"get", "post"} {
+			operation, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+
+			name := strings.ToUpper(method) + " " + path
+			t.Run(name, func(t *testing.T) {
+				url := server.APIPrefix + fillPath(t, path, params)
+				request, err := http.NewRequest(strings.ToUpper(method), url, strings.NewReader(bodies[name]))
+				tests.AssertNoError(t, err)
+				response := httptest.NewRecorder()
+
+				playerServer.ServeHTTP(response, request)
+
+				if method == "get" && response.Code != http.StatusOK {
+					t.Errorf("got status %d for %s, want 200", response.Code, url)
+				}
+				assertDocumented(t, spec, operation, response)
+			})
+		}
+	}
+}
+
+func TestAPI(t *testing.T) {
+	playerServer, _ := newAPIServer(t)
+
+	t.Run("it answers with the player after a win", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodPost, server.APIPrefix+"/players/Pepper/wins"))
+
+		tests.AssertStatus(t, response, http.StatusCreated)
+		tests.AssertContentType(t, response, server.JSONContentType)
+
+		var player server.APIPlayer
+		if err := json.NewDecoder(response.Body).Decode(&player); err != nil {
+			t.Fatalf("couldn't decode the player: %v", err)
+		}
+		if player != (server.APIPlayer{Name: "Pepper", Wins: 20}) {
+			t.Errorf("got player %+v, want Pepper with 20 wins", player)
+		}
+	})
+
+	t.Run("it serves named leagues", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, server.APIPrefix+"/leagues/tuesday/players/Floyd"))
+
+		tests.AssertStatus(t, response, http.StatusOK)
+	})
+
+	t.Run("it refuses leagues with fields it doesn't know", func(t *testing.T) {
+		request, _ := http.NewRequest(http.MethodPost, server.APIPrefix+"/leagues", strings.NewReader(`{"id": "monday", "day": 1}`))
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		assertProblem(t, response, http.StatusBadRequest)
+	})
+
+	t.Run("it answers 404 with a problem for unknown resources", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, server.APIPrefix+"/scores"))
+
+		assertProblem(t, response, http.StatusNotFound)
+	})
+
+	t.Run("it answers 404 with a problem for matches never played", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, server.APIPrefix+"/matches/nope"))
+
+		assertProblem(t, response, http.StatusNotFound)
+	})
+}
+
+// newAPIServer makes a server with something in every resource, returning
+// the path parameters naming them.
+func newAPIServer(t *testing.T) (*server.PlayerServer, map[string]string) {
+	t.Helper()
+
+	at := time.Date(2025, time.March, 1, 20, 0, 0, 0, time.UTC)
+	store := tests.StubPlayerStore{
+		Scores:  map[string]int{"Pepper": 20, "Floyd": 10},
+		League:  engine.League{{Name: "Pepper", Wins: 20}, {Name: "Floyd", Wins: 10}},
+		Matches: []engine.Match{{ID: "m1", Game: "holdem", StartedAt: at, FinishedAt: at.Add(time.Hour), Entrants: 2, Winner: "Pepper"}},
+		Seasons: []engine.Season{{ID: "2024", StartedAt: at.AddDate(-1, 0, 0), EndedAt: at, Standings: engine.League{{Name: "Floyd", Wins: 3}}}},
+	}
+	leagues := tests.StubLeagues{
+		Stores: map[string]*tests.StubPlayerStore{
+			"tuesday": {Scores: map[string]int{"Floyd": 2}, League: engine.League{{Name: "Floyd", Wins: 2}}},
+		},
+	}
+	game := &tests.GameSpy{Schedule: &tests.SpyBlindSchedule{}}
+
+	players := tests.StubPlayerRegistry{Players: engine.Registry{{ID: "pepper", Name: "Pepper", CreatedAt: at}}}
+
+	playerServer := mustMakePlayerServer(t, &store, game, server.WithLeagues(&leagues), server.WithPlayers(&players))
+
+	response := httptest.NewRecorder()
+	playerServer.ServeHTTP(response, newRequest(http.MethodPost, server.APIPrefix+"/games"))
+	tests.AssertStatus(t, response, http.StatusCreated)
+
+	return playerServer, map[string]string{
+		"name":   "Pepper",
+		"match":  "m1",
+		"season": "2024",
+		"league": "tuesday",
+		"id":     getGameFromResponse(t, response.Body).ID,
+		"action": "pause",
+	}
+}
+
+func getOpenAPI(t testing.TB, playerServer http.Handler) map[string]any {
+	t.Helper()
+
+	response := httptest.NewRecorder()
+	playerServer.ServeHTTP(response, newRequest(http.MethodGet, server.APIPrefix+"/openapi.json"))
+	tests.AssertStatus(t, response, http.StatusOK)
+
+	var spec map[string]any
+	if err := json.NewDecoder(response.Body).Decode(&spec); err != nil {
+		t.Fatalf("couldn't decode the OpenAPI document: %v", err)
+	}
+
+	return spec
+}
+
+// fillPath replaces the parameters of path with those named in params.
+func fillPath(t testing.TB, path string, params map[string]string) string {
+	t.Helper()
+
+	for name, value := range params {
+		path = strings.ReplaceAll(path, "{"+name+"}", value)
+	}
+
+	if strings.Contains(path, "{") {
+		t.Fatalf("no parameter to fill %s with", path)
+	}
+
+	return path
+}
+
+// assertDocumented checks the response is one the operation describes, with
+// the content type and body it says.
+func assertDocumented(t testing.TB, spec, operation map[string]any, response *httptest.ResponseRecorder) {
+	t.Helper()
+
+	responses := operation["responses"].(map[string]any)
+	documented, ok := responses[strconv.Itoa(response.Code)]
+	if !ok {
+		t.Fatalf("status %d isn't documented, got %q", response.Code, response.Body)
+	}
+
+	content := resolve(t, spec, documented.(map[string]any))["content"].(map[string]any)
+	contentType := response.Header().Get("content-type")
+	media, ok := content[contentType].(map[string]any)
+	if !ok {
+		t.Fatalf("content type %q isn't documented for %d, want one of %v", contentType, response.Code, slices.Collect(maps.Keys(content)))
+	}
+
+	var body any
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		t.Fatalf("couldn't decode the body: %v", err)
+	}
+
+	for _, problem := range validate(spec, media["schema"].(map[string]any), body, "body") {
+		t.Error(problem)
+	}
+}
+
+// validate reports how value doesn't match schema, following the parts of
+// JSON schema the OpenAPI document uses.
+func validate(spec, schema map[string]any, value any, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return validate(spec, lookup(spec, ref), value, at)
+	}
+
+	var problems []string
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		problems = append(problems, at+" isn't one of the documented values")
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return append(problems, at+" isn't an object")
+		}
+
+		// objects listing no properties may have any
+		properties, listed := schema["properties"].(map[string]any)
+		for name := range object {
+			if _, ok := properties[name]; listed && !ok {
+				problems = append(problems, at+"."+name+" isn't documented")
+			}
+		}
+
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				problems = append(problems, at+"."+name.(string)+" is missing")
+			}
+		}
+
+		for name, property := range properties {
+			if v, ok := object[name]; ok {
+				problems = append(problems, validate(spec, property.(map[string]any), v, at+"."+name)...)
+			}
+		}
+
+		if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+			for name, v := range object {
+				problems = append(problems, validate(spec, additional, v, at+"."+name)...)
+			}
+		}
+
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return append(problems, at+" isn't an array")
+		}
+
+		for i, v := range array {
+			problems = append(problems, validate(spec, schema["items"].(map[string]any), v, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return append(problems, at+" isn't a string")
+		}
+
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				problems = append(problems, at+" isn't a date-time")
+			}
+		}
+
+	case "integer":
+		if n, ok := value.(float64); !ok || n != float64(int64(n)) {
+			problems = append(problems, at+" isn't an integer")
+		}
+
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, at+" isn't a number")
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, at+" isn't a boolean")
+		}
+	}
+
+	return problems
+}
+
+// resolve follows the reference of object, if it is one.
+func resolve(t testing.TB, spec, object map[string]any) map[string]any {
+	t.Helper()
+
+	ref, ok := object["$ref"].(string)
+	if !ok {
+		return object
+	}
+
+	resolved := lookup(spec, ref)
+	if resolved == nil {
+		t.Fatalf("couldn't resolve %s", ref)
+	}
+
+	return resolved
+}
+
+func lookup(spec map[string]any, ref string) map[string]any {
+	object := spec
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, _ = object[part].(map[string]any)
+	}
+
+	return object
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+var errNotAllowed = errors.New("only scorekeepers can start and finish games, log in or connect with a key")
+
+// Caller is who made a request, and what they may do.
+type Caller struct {
+	Name string      `json:"name"`
+	Role engine.Role `json:"role"`
+}
+
+type callerKey struct{}
+
+// unguarded are the routes guard lets through: pages anyone may load, and
+// those guarded again further down, once their league is known.
+var unguarded = []string{"/", "/game", "/login", "/logout", "/openapi.json", "/leagues/{league}/", APIPrefix + "/"}
+
+// adminRoutes are the routes only admins may change anything through,
+// everything else is changed by scorekeepers.
+var adminRoutes = []string{"/seasons/", "/seasons/{season}", "/leagues", "/leagues/{id}", "/corrections", "/players/{name}/aliases", "/players/{name}/merge"}
+
+// WithPrivateReads makes reading a league take a viewer too.
+func WithPrivateReads() Option {
+	return func(p *PlayerServer) {
+		p.privateReads = true
+	}
+}
+
+// guarded reports whether callers have to say who they are, which they
+// don't until the server has keys or users.
+func (p *PlayerServer) guarded() bool {
+	return p.keys != nil || p.users != nil
+}
+
+// guard answers requests to next with 401 when they don't say who made them
+// and need to, and with 403 when their caller's role doesn't allow them.
+func (p *PlayerServer) guard(next *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := next.Handler(r)
+		if !p.guarded() || slices.Contains(unguarded, pattern) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		who, err := p.identify(r)
+		if errors.Is(err, engine.ErrBadKey) {
+			unauthorized(w, err.Error())
+			return
+		}
+		if err != nil {
+			log.Println("couldn't tell who made the request: ", err)
+			writeProblem(w, http.StatusInternalServerError, "couldn't tell who you are")
+			return
+		}
+
+		role := neededRole(r.Method, pattern)
+		switch {
+		case role == engine.RoleViewer && !p.privateReads:
+		case who == nil:
+			unauthorized(w, "log in, or send an API key as a bearer token")
+			return
+		case !who.Role.Allows(role):
+			writeProblem(w, http.StatusForbidden, fmt.Sprintf("%s is a %s, only a %s can do that", who.Name, who.Role, role))
+			return
+		}
+
+		if who != nil {
+			r = r.WithContext(context.WithValue(r.Context(), callerKey{}, *who))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// identify returns who made r, from its API key or the user it's logged in
+// as, or nil for someone unknown.
+func (p *PlayerServer) identify(r *http.Request) (*Caller, error) {
+	if secret, ok := apiKey(r); ok {
+		who, err := p.keyCaller(r.Context(), secret)
+		if err != nil {
+			return nil, err
+		}
+
+		return &who, nil
+	}
+
+	return p.loggedIn(r)
+}
+
+// allows reports whether the caller of ctx is allowed what role is.
+func (p *PlayerServer) allows(ctx context.Context, role engine.Role) bool {
+	if !p.guarded() {
+		return true
+	}
+
+	who, ok := ctx.Value(callerKey{}).(Caller)
+	return ok && who.Role.Allows(role)
+}
+
+// callerName returns the name of the caller of ctx, for the audit log, or
+// anonymous on a server nobody has to say who they are to.
+func callerName(ctx context.Context) string {
+	if who, ok := ctx.Value(callerKey{}).(Caller); ok {
+		return who.Name
+	}
+
+	return "anonymous"
+}
+
+// neededRole returns the role a request with method to the route of pattern
+// takes.
+func neededRole(method, pattern string) engine.Role {
+	switch {
+	case method == http.MethodGet || method == http.MethodHead:
+		return engine.RoleViewer
+	case slices.Contains(adminRoutes, pattern):
+		return engine.RoleAdmin
+	default:
+		return engine.RoleScorekeeper
+	}
+}
+
+// refuse answers r, whose caller isn't allowed what role is, with 401 if they
+// didn't say who they are and with 403 if they did.
+func refuse(w http.ResponseWriter, r *http.Request, role engine.Role) {
+	who, ok := r.Context().Value(callerKey{}).(Caller)
+	if !ok {
+		unauthorized(w, "log in, or send an API key as a bearer token")
+		return
+	}
+
+	writeProblem(w, http.StatusForbidden, fmt.Sprintf("%s is a %s, only a %s can do that", who.Name, who.Role, role))
+}
+
+func unauthorized(w http.ResponseWriter, detail string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="game-score-server"`)
+	writeProblem(w, http.StatusUnauthorized, detail)
+}
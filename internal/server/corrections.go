@@ -0,0 +1,70 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// correctionsHandler corrects a result of the league as described by the
+// body, answering with the audit entry recording it.
+func (p *PlayerServer) correctionsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "results can only be corrected with POST", http.MethodPost) {
+		return
+	}
+
+	corrector, ok := p.storeFor(r.Context()).(engine.Corrector)
+	if !ok {
+		writeProblem(w, http.StatusNotImplemented, "the results of this league can't be corrected")
+		return
+	}
+
+	var correction engine.Correction
+	if err := decodeJSON(r, &correction); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entry, err := corrector.Correct(r.Context(), correction, callerName(r.Context()), p.clock.Now().UTC())
+	switch {
+	case errors.Is(err, engine.ErrMatchNotFound):
+		writeProblem(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, engine.ErrSeasonClosed):
+		writeProblem(w, http.StatusConflict, err.Error())
+	case errors.Is(err, engine.ErrBadCorrection), errors.Is(err, engine.ErrBadPlayerName):
+		writeProblem(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		log.Println("couldn't correct the result: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't correct the result")
+	default:
+		writeJSON(w, http.StatusCreated, entry)
+	}
+}
+
+// auditHandler lists every correction made to the league, oldest first.
+func (p *PlayerServer) auditHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "the audit log can only be read with GET", http.MethodGet) {
+		return
+	}
+
+	corrector, ok := p.storeFor(r.Context()).(engine.Corrector)
+	if !ok {
+		writeProblem(w, http.StatusNotImplemented, "the results of this league can't be corrected")
+		return
+	}
+
+	audit, err := corrector.GetAudit(r.Context())
+	if err != nil {
+		log.Println("couldn't get the audit log: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't get the audit log")
+		return
+	}
+
+	if audit == nil {
+		audit = []engine.AuditEntry{}
+	}
+
+	writeJSON(w, http.StatusOK, audit)
+}
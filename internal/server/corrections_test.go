@@ -0,0 +1,87 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/server"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestCorrections(t *testing.T) {
+	users := tests.NewStubUserStore(t)
+	at := time.Date(2026, time.May, 2, 21, 0, 0, 0, time.UTC)
+	store := tests.StubPlayerStore{
+		Scores:  map[string]int{"Pepper": 1},
+		League:  engine.League{{Name: "Pepper", Wins: 1}},
+		Matches: []engine.Match{{ID: "m1", FinishedAt: at, Participants: []string{"Pepper", "Floyd"}, Winner: "Pepper"}},
+	}
+	clock := tests.NewManualClock(at.Add(time.Hour))
+	playerServer := mustMakePlayerServer(t, &store, tests.DummyGame, server.WithUsers(users), server.WithClock(clock))
+	admin := mustLogIn(t, playerServer, "admin", "password")
+
+	t.Run("only admins correct results", func(t *testing.T) {
+		request := newCorrectionRequest("/corrections", `{"action": "void", "match": "m1"}`)
+		request.AddCookie(mustLogIn(t, playerServer, "scorekeeper", "password"))
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		assertProblem(t, response, http.StatusForbidden)
+	})
+
+	t.Run("it reassigns a match and audits who did", func(t *testing.T) {
+		request := newCorrectionRequest("/corrections", `{"action": "reassign", "match": "m1", "winner": "Floyd", "reason": "misclicked"}`)
+		request.AddCookie(admin)
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		tests.AssertStatus(t, response, http.StatusCreated)
+		tests.AssertPlayerScore(t, &store, "Floyd", 1)
+		tests.AssertPlayerScore(t, &store, "Pepper", 0)
+
+		request = newRequest(http.MethodGet, "/audit")
+		request.AddCookie(admin)
+		response = httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		var audit []engine.AuditEntry
+		if err := json.NewDecoder(response.Body).Decode(&audit); err != nil {
+			t.Fatalf("couldn't decode the audit log: %v", err)
+		}
+		if len(audit) != 1 || audit[0].By != "admin" || !audit[0].At.Equal(clock.Now()) || audit[0].Before.Match.Winner != "Pepper" {
+			t.Errorf("got audit log %+v, want the match reassigned from Pepper by admin", audit)
+		}
+	})
+
+	t.Run("it answers problems for corrections it can't make", func(t *testing.T) {
+		cases := map[string]int{
+			`{"action": "void", "match": "nope"}`:                   http.StatusNotFound,
+			`{"action": "adjust", "player": "Floyd", "wins": -5}`:   http.StatusBadRequest,
+			`{"action": "adjust", "player": "Floyd", "by": "root"}`: http.StatusBadRequest,
+		}
+
+		for body, want := range cases {
+			request := newCorrectionRequest(server.APIPrefix+"/corrections", body)
+			request.AddCookie(admin)
+			response := httptest.NewRecorder()
+
+			playerServer.ServeHTTP(response, request)
+
+			assertProblem(t, response, want)
+		}
+	})
+}
+
+func newCorrectionRequest(path, body string) *http.Request {
+	request, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	return request
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/oblassov/game-score-server/internal/session"
+)
+
+// gamesHandler lists the games on GET and sets up a new one, waiting for
+// players to join it on /ws?game=$id, on POST.
+func (p *PlayerServer) gamesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, p.games.List())
+	case http.MethodPost:
+		writeJSON(w, http.StatusCreated, p.games.Create())
+	default:
+		methodNotAllowed(w, "games can only be listed with GET or created with POST", http.MethodGet, http.MethodPost)
+	}
+}
+
+func (p *PlayerServer) gameHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "games can only be checked with GET", http.MethodGet) {
+		return
+	}
+
+	game, err := p.games.Get(r.PathValue("id"))
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, game)
+}
+
+// changeGame pauses or resumes a game, as named by the last part of the path.
+func (p *PlayerServer) changeGame(w http.ResponseWriter, r *http.Request) {
+	var change func(id string) (session.Session, error)
+
+	switch r.PathValue("action") {
+	case "pause":
+		change = p.games.Pause
+	case "resume":
+		change = p.games.Resume
+	default:
+		writeProblem(w, http.StatusNotFound, fmt.Sprintf("games can't %s, only pause and resume", r.PathValue("action")))
+		return
+	}
+
+	if !allowMethods(w, r, "games can only be paused and resumed with POST", http.MethodPost) {
+		return
+	}
+
+	game, err := change(r.PathValue("id"))
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, game)
+}
+
+// gameWaiting makes sure game id can be joined, reporting why not to w if
+// it can't.
+func (p *PlayerServer) gameWaiting(w http.ResponseWriter, id string) bool {
+	game, err := p.games.Get(id)
+	if err != nil {
+		writeGameError(w, err)
+		return false
+	}
+
+	if game.State != session.Waiting {
+		writeProblem(w, http.StatusConflict, "the game is "+string(game.State)+", only waiting games can be joined")
+		return false
+	}
+
+	return true
+}
+
+// watchGame passes on the blinds and state of game id to a websocket until it
+// closes, ignoring everything but pings from it.
+func (p *PlayerServer) watchGame(w http.ResponseWriter, r *http.Request, id string) {
+	game, err := p.games.Get(id)
+	if err != nil {
+		writeGameError(w, err)
+		return
+	}
+	if game.State == session.Finished {
+		writeProblem(w, http.StatusConflict, "the game is finished, there's nothing left to watch")
+		return
+	}
+
+	ws, err := newPlayerServerWS(w, r)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer func() {
+		if err := ws.Close(); err != nil {
+			log.Println("couldn't close the websocket: ", err)
+		}
+	}()
+
+	game, err = p.games.Watch(id, ws)
+	if err != nil {
+		p.send(ws, MsgError, ErrorMessage{Message: err.Error()})
+		return
+	}
+	defer func() {
+		if _, err := p.games.Unwatch(id, ws); err != nil {
+			log.Println("couldn't stop watching the game: ", err)
+		}
+	}()
+
+	p.send(ws, MsgState, StateMessage{Session: game})
+	if game.Level != nil {
+		p.send(ws, MsgBlind, BlindMessage{Level: game.Level, Text: game.Level.String()})
+	}
+
+	for {
+		msg, err := ws.WaitForMsg()
+		switch {
+		case err == nil && msg.Type == MsgPing:
+			p.send(ws, MsgPing, nil)
+		case err == nil:
+			p.send(ws, MsgError, ErrorMessage{Message: fmt.Sprintf("%v, watchers can't send %s messages", errBadMessage, msg.Type)})
+		case errors.Is(err, errBadMessage):
+			p.send(ws, MsgError, ErrorMessage{Message: err.Error()})
+		default:
+			return
+		}
+	}
+}
+
+func writeGameError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, session.ErrGameNotFound):
+		writeProblem(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, session.ErrWrongState):
+		writeProblem(w, http.StatusConflict, err.Error())
+	case errors.Is(err, session.ErrBadToken):
+		writeProblem(w, http.StatusForbidden, err.Error())
+	default:
+		log.Println("couldn't change the game: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't change the game")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("content-type", JSONContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("couldn't encode the json: ", err)
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+type keysKey struct{}
+
+// WithAPIKeys lets callers holding one of keys, or a key of the named league
+// they call, do what the key's role allows.
+func WithAPIKeys(keys engine.KeyStore) Option {
+	return func(p *PlayerServer) {
+		p.keys = keys
+	}
+}
+
+// keyCaller returns who holds the key secret belongs to, be it one of the
+// server or one of the named league the request was routed to.
+func (p *PlayerServer) keyCaller(ctx context.Context, secret string) (Caller, error) {
+	var key engine.APIKey
+	err := engine.ErrBadKey
+
+	if p.keys != nil {
+		key, err = engine.CheckAPIKey(ctx, p.keys, secret)
+	}
+	if keys, ok := ctx.Value(keysKey{}).(engine.KeyStore); ok && errors.Is(err, engine.ErrBadKey) {
+		key, err = engine.CheckAPIKey(ctx, keys, secret)
+	}
+	if err != nil {
+		return Caller{}, err
+	}
+
+	return Caller{Name: "key " + key.Name, Role: key.Allowed()}, nil
+}
+
+// leagueKeys returns the API keys of named league id, if the leagues keep
+// any.
+func (p *PlayerServer) leagueKeys(ctx context.Context, id string) (engine.KeyStore, error) {
+	leagues, ok := p.leagues.(engine.KeyedLeagues)
+	if !ok || !p.guarded() {
+		return nil, nil
+	}
+
+	return leagues.Keys(ctx, id)
+}
+
+// apiKey returns the bearer token of r. Browsers can't set headers on
+// websockets, so those may pass it as the key parameter instead.
+func apiKey(r *http.Request) (string, bool) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return token, true
+	}
+
+	if websocket.IsWebSocketUpgrade(r) && r.URL.Query().Has("key") {
+		return r.URL.Query().Get("key"), true
+	}
+
+	return "", false
+}
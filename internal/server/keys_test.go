@@ -0,0 +1,122 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/server"
+	"github.com/oblassov/game-score-server/internal/session"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestAPIKeys(t *testing.T) {
+	keys, secret := tests.NewStubKeyStore(t, "club", engine.RoleAdmin)
+	fridayKeys, fridaySecret := tests.NewStubKeyStore(t, "friday", engine.RoleScorekeeper)
+	store := tests.StubPlayerStore{Scores: map[string]int{"Pepper": 1}}
+	leagues := tests.StubLeagues{
+		Stores:    map[string]*tests.StubPlayerStore{"friday": {Scores: map[string]int{"Floyd": 1}}},
+		KeyStores: map[string]*tests.StubKeyStore{"friday": fridayKeys},
+	}
+	game := &tests.GameSpy{Level: &engine.BlindLevel{SmallBlind: 100, BigBlind: 200}}
+	playerServer := mustMakePlayerServer(t, &store, game, server.WithAPIKeys(keys), server.WithLeagues(&leagues))
+
+	t.Run("it takes a key to change the leagues", func(t *testing.T) {
+		cases := []struct {
+			method, path, key string
+			want              int
+		}{
+			{http.MethodPost, "/players/Pepper", "", http.StatusUnauthorized},
+			{http.MethodPost, "/players/Pepper", "gss_nope", http.StatusUnauthorized},
+			{http.MethodPost, "/players/Pepper", secret, http.StatusAccepted},
+			{http.MethodPost, "/players/Pepper", fridaySecret, http.StatusUnauthorized},
+			{http.MethodPost, server.APIPrefix + "/players/Pepper/wins", "", http.StatusUnauthorized},
+			{http.MethodPost, server.APIPrefix + "/players/Pepper/wins", secret, http.StatusCreated},
+			{http.MethodPost, "/leagues/friday/players/Floyd", fridaySecret, http.StatusAccepted},
+			{http.MethodPost, "/leagues/friday/players/Floyd", secret, http.StatusAccepted},
+			{http.MethodPost, "/leagues/friday/players/Floyd", "", http.StatusUnauthorized},
+			{http.MethodPost, server.APIPrefix + "/leagues/friday/players/Floyd/wins", fridaySecret, http.StatusCreated},
+			{http.MethodPost, "/leagues/sunday", fridaySecret, http.StatusUnauthorized},
+			{http.MethodPost, "/games", "", http.StatusUnauthorized},
+		}
+
+		for _, c := range cases {
+			request := newRequest(c.method, c.path)
+			if c.key != "" {
+				request.Header.Set("Authorization", "Bearer "+c.key)
+			}
+			response := httptest.NewRecorder()
+
+			playerServer.ServeHTTP(response, request)
+
+			if response.Code != c.want {
+				t.Errorf("got status %d for %s %s with key %q, want %d", response.Code, c.method, c.path, c.key, c.want)
+			}
+			if c.want == http.StatusUnauthorized && !strings.HasPrefix(response.Header().Get("WWW-Authenticate"), "Bearer") {
+				t.Errorf("got no bearer challenge for %s %s", c.method, c.path)
+			}
+		}
+	})
+
+	t.Run("it lets anyone read the leagues", func(t *testing.T) {
+		for _, path := range []string{"/players/Pepper", "/league", "/leagues/friday/league", server.APIPrefix + "/players", "/game", "/"} {
+			response := httptest.NewRecorder()
+
+			playerServer.ServeHTTP(response, newRequest(http.MethodGet, path))
+
+			tests.AssertStatus(t, response, http.StatusOK)
+		}
+	})
+
+	t.Run("it takes a key allowed to", func(t *testing.T) {
+		request := newRequest(http.MethodPost, "/leagues/friday/seasons/2025-spring")
+		request.Header.Set("Authorization", "Bearer "+fridaySecret)
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		assertProblem(t, response, http.StatusForbidden)
+	})
+
+	t.Run("it takes a key to read private leagues", func(t *testing.T) {
+		playerServer := mustMakePlayerServer(t, &store, game, server.WithAPIKeys(keys), server.WithPrivateReads())
+
+		response := httptest.NewRecorder()
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/league"))
+		assertProblem(t, response, http.StatusUnauthorized)
+
+		request := newRequest(http.MethodGet, "/league")
+		request.Header.Set("Authorization", "Bearer "+secret)
+		response = httptest.NewRecorder()
+		playerServer.ServeHTTP(response, request)
+		tests.AssertStatus(t, response, http.StatusOK)
+
+		response = httptest.NewRecorder()
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/game"))
+		tests.AssertStatus(t, response, http.StatusOK)
+	})
+
+	t.Run("it takes a key to play a game over a websocket", func(t *testing.T) {
+		store.WinCalls = nil
+		testServer := httptest.NewServer(playerServer)
+		defer testServer.Close()
+		wsURL := "ws" + strings.TrimPrefix(testServer.URL, "http") + "/ws"
+
+		assertDialRefused(t, wsURL, http.StatusUnauthorized)
+
+		ws := mustDialWS(t, wsURL+"?key="+url.QueryEscape(secret))
+		defer closeWS(t, ws)
+		writeMessage(t, ws, server.MsgStart, server.StartMessage{Players: 3})
+		state := getState(t, waitForMessages(t, ws, server.MsgBlind, server.MsgState)[server.MsgState])
+		if state.State != session.Running {
+			t.Errorf("got game %s, want %s", state.State, session.Running)
+		}
+		assertDialRefused(t, wsURL+"?game="+state.ID+"&token="+url.QueryEscape(state.Token), http.StatusUnauthorized)
+
+		writeMessage(t, ws, server.MsgFinish, server.FinishMessage{Winner: "Ruth"})
+		assertGameState(t, waitForMessage(t, ws, server.MsgState), session.Finished)
+	})
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+type storeKey struct{}
+
+// storeFor returns the store of the named league a request was routed to,
+// or the server's own store outside of /leagues.
+func (p *PlayerServer) storeFor(ctx context.Context) engine.PlayerStore {
+	if store, ok := ctx.Value(storeKey{}).(engine.PlayerStore); ok {
+		return store
+	}
+
+	return p.store
+}
+
+func (p *PlayerServer) leaguesHandler(w http.ResponseWriter, r *http.Request) {
+	if p.leagues == nil {
+		writeProblem(w, http.StatusNotFound, noLeagues)
+		return
+	}
+
+	if !allowMethods(w, r, "leagues can only be listed with GET", http.MethodGet) {
+		return
+	}
+
+	ids, err := p.leagues.ListLeagues(r.Context())
+	if err != nil {
+		log.Println("couldn't list the leagues: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't list the leagues")
+		return
+	}
+
+	if ids == nil {
+		ids = []string{}
+	}
+
+	w.Header().Set("content-type", JSONContentType)
+	if err := json.NewEncoder(w).Encode(ids); err != nil {
+		log.Println("couldn't encode the json: ", err)
+	}
+}
+
+// createLeague starts the league named in the path with a store of its own.
+func (p *PlayerServer) createLeague(w http.ResponseWriter, r *http.Request) {
+	if p.leagues == nil {
+		writeProblem(w, http.StatusNotFound, noLeagues)
+		return
+	}
+
+	if !allowMethods(w, r, "leagues can only be created with POST", http.MethodPost) {
+		return
+	}
+
+	if err := p.leagues.CreateLeague(r.Context(), r.PathValue("id")); err != nil {
+		writeCreateLeagueError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func writeCreateLeagueError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, engine.ErrBadLeagueID):
+		writeProblem(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, engine.ErrLeagueExists):
+		writeProblem(w, http.StatusConflict, err.Error())
+	default:
+		log.Println("couldn't create the league: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't create the league")
+	}
+}
+
+// noLeagues is the detail of the 404 for named leagues when the server
+// doesn't serve any.
+const noLeagues = "this server only has the one league"
+
+// inLeague serves requests under /leagues/{league}/ with next, as if they were
+// made to the server of that league alone.
+func (p *PlayerServer) inLeague(next *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.leagues == nil {
+			writeProblem(w, http.StatusNotFound, noLeagues)
+			return
+		}
+
+		id := r.PathValue("league")
+
+		store, err := p.leagues.Store(r.Context(), id)
+		if errors.Is(err, engine.ErrLeagueNotFound) {
+			writeProblem(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			log.Println("couldn't open the league: ", err)
+			writeProblem(w, http.StatusInternalServerError, "couldn't open the league")
+			return
+		}
+
+		keys, err := p.leagueKeys(r.Context(), id)
+		if err != nil {
+			log.Println("couldn't open the keys of the league: ", err)
+			writeProblem(w, http.StatusInternalServerError, "couldn't open the league")
+			return
+		}
+
+		players, err := p.leaguePlayers(r.Context(), id)
+		if err != nil {
+			log.Println("couldn't open the player registry of the league: ", err)
+			writeProblem(w, http.StatusInternalServerError, "couldn't open the league")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), storeKey{}, store)
+		if keys != nil {
+			ctx = context.WithValue(ctx, keysKey{}, keys)
+		}
+		if players != nil {
+			ctx = context.WithValue(ctx, registryKey{}, players)
+		}
+		http.StripPrefix("/leagues/"+id, p.guard(next)).ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// LoginCookie keeps the login of a browser.
+const LoginCookie = "gss_login"
+
+// LoginLifetime is how long a login lasts.
+const LoginLifetime = 12 * time.Hour
+
+// Credentials log a user in.
+type Credentials struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// WithUsers lets users log in to do what their role allows.
+func WithUsers(users engine.UserStore) Option {
+	return func(p *PlayerServer) {
+		p.users = users
+	}
+}
+
+// logins remembers who is logged in with every login cookie, until they log
+// out or it expires.
+type logins struct {
+	lock    sync.Mutex
+	byToken map[string]login
+}
+
+type login struct {
+	name    string
+	expires time.Time
+}
+
+func newLogins() *logins {
+	return &logins{byToken: map[string]login{}}
+}
+
+func (l *logins) start(name string, now time.Time) string {
+	token := make([]byte, 32)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(token)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.byToken[hex.EncodeToString(token)] = login{name: name, expires: now.Add(LoginLifetime)}
+	return hex.EncodeToString(token)
+}
+
+// find returns who is logged in with token, forgetting it once it expired.
+func (l *logins) find(token string, now time.Time) (string, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	login, ok := l.byToken[token]
+	if ok && now.After(login.expires) {
+		delete(l.byToken, token)
+		return "", false
+	}
+
+	return login.name, ok
+}
+
+func (l *logins) end(token string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.byToken, token)
+}
+
+// loggedIn returns the user r is logged in as, or nil if it isn't. Their role
+// is looked up again, so changing it takes effect straight away.
+func (p *PlayerServer) loggedIn(r *http.Request) (*Caller, error) {
+	cookie, err := r.Cookie(LoginCookie)
+	if err != nil || p.users == nil {
+		return nil, nil
+	}
+
+	name, ok := p.logins.find(cookie.Value, p.clock.Now())
+	if !ok {
+		return nil, nil
+	}
+
+	user, err := p.users.GetUser(r.Context(), name)
+	if errors.Is(err, engine.ErrUserNotFound) {
+		p.logins.end(cookie.Value)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Caller{Name: user.Name, Role: user.Role}, nil
+}
+
+// loginHandler returns who is logged in on GET and logs a user in on POST,
+// setting the login cookie.
+func (p *PlayerServer) loginHandler(w http.ResponseWriter, r *http.Request) {
+	if p.users == nil {
+		writeProblem(w, http.StatusNotFound, "this server has no users to log in as")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		who, err := p.loggedIn(r)
+		if err != nil {
+			log.Println("couldn't find the user logged in: ", err)
+			writeProblem(w, http.StatusInternalServerError, "couldn't tell who you are")
+			return
+		}
+		if who == nil {
+			unauthorized(w, "nobody is logged in")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, who)
+
+	case http.MethodPost:
+		var credentials Credentials
+		if err := decodeJSON(r, &credentials); err != nil {
+			writeProblem(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		user, err := engine.Login(r.Context(), p.users, credentials.Name, credentials.Password)
+		if errors.Is(err, engine.ErrBadCredentials) {
+			unauthorized(w, err.Error())
+			return
+		}
+		if err != nil {
+			log.Println("couldn't log in: ", err)
+			writeProblem(w, http.StatusInternalServerError, "couldn't log in")
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     LoginCookie,
+			Value:    p.logins.start(user.Name, p.clock.Now()),
+			Path:     "/",
+			MaxAge:   int(LoginLifetime.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			// the cookie is never sent along with requests from other sites
+			SameSite: http.SameSiteStrictMode,
+		})
+		writeJSON(w, http.StatusOK, Caller{Name: user.Name, Role: user.Role})
+
+	default:
+		methodNotAllowed(w, "logins can only be checked with GET or made with POST", http.MethodGet, http.MethodPost)
+	}
+}
+
+func (p *PlayerServer) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "logging out takes POST", http.MethodPost) {
+		return
+	}
+
+	if cookie, err := r.Cookie(LoginCookie); err == nil {
+		p.logins.end(cookie.Value)
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: LoginCookie, Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	w.WriteHeader(http.StatusNoContent)
+}
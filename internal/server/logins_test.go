@@ -0,0 +1,157 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/server"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestRoles(t *testing.T) {
+	users := tests.NewStubUserStore(t)
+	store := tests.StubPlayerStore{Scores: map[string]int{"Pepper": 1}}
+	playerServer := mustMakePlayerServer(t, &store, tests.DummyGame, server.WithUsers(users), server.WithPrivateReads())
+
+	cookies := map[engine.Role]*http.Cookie{}
+	for _, role := range engine.Roles {
+		cookies[role] = mustLogIn(t, playerServer, string(role), "password")
+	}
+
+	cases := []struct {
+		method, path string
+		want         map[engine.Role]int
+	}{
+		{http.MethodGet, "/league", map[engine.Role]int{"": http.StatusUnauthorized, engine.RoleViewer: http.StatusOK}},
+		{http.MethodPost, "/players/Pepper", map[engine.Role]int{
+			engine.RoleViewer:      http.StatusForbidden,
+			engine.RoleScorekeeper: http.StatusAccepted,
+			engine.RoleAdmin:       http.StatusAccepted,
+		}},
+		{http.MethodPost, "/seasons/2025-spring", map[engine.Role]int{
+			engine.RoleScorekeeper: http.StatusForbidden,
+			engine.RoleAdmin:       http.StatusCreated,
+		}},
+		{http.MethodPost, server.APIPrefix + "/players/Pepper/wins", map[engine.Role]int{
+			"":                     http.StatusUnauthorized,
+			engine.RoleViewer:      http.StatusForbidden,
+			engine.RoleScorekeeper: http.StatusCreated,
+		}},
+		{http.MethodPost, "/games", map[engine.Role]int{engine.RoleViewer: http.StatusForbidden, engine.RoleScorekeeper: http.StatusCreated}},
+	}
+
+	for _, c := range cases {
+		for role, want := range c.want {
+			request := newRequest(c.method, c.path)
+			if cookie, ok := cookies[role]; ok {
+				request.AddCookie(cookie)
+			}
+			response := httptest.NewRecorder()
+
+			playerServer.ServeHTTP(response, request)
+
+			if response.Code != want {
+				t.Errorf("got status %d for %s %s as %q, want %d", response.Code, c.method, c.path, role, want)
+			}
+		}
+	}
+}
+
+func TestLogins(t *testing.T) {
+	users := tests.NewStubUserStore(t)
+	clock := tests.NewManualClock(time.Date(2026, time.March, 14, 19, 0, 0, 0, time.UTC))
+	playerServer := mustMakePlayerServer(t, tests.DummyPlayerStore, tests.DummyGame, server.WithUsers(users), server.WithClock(clock))
+
+	t.Run("it tells who is logged in", func(t *testing.T) {
+		request := newRequest(http.MethodGet, "/login")
+		request.AddCookie(mustLogIn(t, playerServer, "admin", "password"))
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		var who server.Caller
+		if err := json.NewDecoder(response.Body).Decode(&who); err != nil {
+			t.Fatalf("couldn't decode who is logged in: %v", err)
+		}
+		if who != (server.Caller{Name: "admin", Role: engine.RoleAdmin}) {
+			t.Errorf("got %+v, want the admin", who)
+		}
+	})
+
+	t.Run("it refuses wrong passwords", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newLoginRequest("admin", "Password"))
+
+		assertProblem(t, response, http.StatusUnauthorized)
+		if len(response.Result().Cookies()) != 0 {
+			t.Errorf("got cookies %v, want none", response.Result().Cookies())
+		}
+	})
+
+	t.Run("it forgets logins on logout and once they expire", func(t *testing.T) {
+		cookie := mustLogIn(t, playerServer, "viewer", "password")
+		if !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+			t.Errorf("got cookie %+v, want it kept from scripts and other sites", cookie)
+		}
+
+		request := newRequest(http.MethodPost, "/logout")
+		request.AddCookie(cookie)
+		playerServer.ServeHTTP(httptest.NewRecorder(), request)
+		assertLoggedOut(t, playerServer, cookie)
+
+		cookie = mustLogIn(t, playerServer, "viewer", "password")
+		clock.Advance(server.LoginLifetime + time.Second)
+		assertLoggedOut(t, playerServer, cookie)
+	})
+
+	t.Run("it has nobody to log in without users", func(t *testing.T) {
+		playerServer := mustMakePlayerServer(t, tests.DummyPlayerStore, tests.DummyGame)
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newLoginRequest("admin", "password"))
+
+		assertProblem(t, response, http.StatusNotFound)
+	})
+}
+
+func mustLogIn(t testing.TB, playerServer http.Handler, name, password string) *http.Cookie {
+	t.Helper()
+
+	response := httptest.NewRecorder()
+	playerServer.ServeHTTP(response, newLoginRequest(name, password))
+	tests.AssertStatus(t, response, http.StatusOK)
+
+	for _, cookie := range response.Result().Cookies() {
+		if cookie.Name == server.LoginCookie {
+			return cookie
+		}
+	}
+
+	t.Fatalf("didn't get a login cookie logging in as %s", name)
+	return nil
+}
+
+func assertLoggedOut(t testing.TB, playerServer http.Handler, cookie *http.Cookie) {
+	t.Helper()
+
+	request := newRequest(http.MethodGet, "/login")
+	request.AddCookie(cookie)
+	response := httptest.NewRecorder()
+
+	playerServer.ServeHTTP(response, request)
+
+	tests.AssertStatus(t, response, http.StatusUnauthorized)
+}
+
+func newLoginRequest(name, password string) *http.Request {
+	body, _ := json.Marshal(server.Credentials{Name: name, Password: password})
+	request, _ := http.NewRequest(http.MethodPost, "/login", strings.NewReader(string(body)))
+	return request
+}
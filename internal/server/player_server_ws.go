@@ -1,40 +1,86 @@
 package server
 
 import (
-	"log"
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/session"
 )
 
+// wsWriteTimeout is how long a message may take to be sent before the
+// connection is given up on.
+const wsWriteTimeout = 10 * time.Second
+
 type playerServerWS struct {
 	*websocket.Conn
+	// writeLock serializes writes, as blinds are alerted from their own
+	// goroutine.
+	writeLock sync.Mutex
 }
 
-func newPlayerServerWS(w http.ResponseWriter, r *http.Request) *playerServerWS {
+func newPlayerServerWS(w http.ResponseWriter, r *http.Request) (*playerServerWS, error) {
 	conn, err := wsUpgrader.Upgrade(w, r, nil)
 
 	if err != nil {
-		log.Printf("problem upgrading connection to WebSockets %v\n", err)
+		return nil, fmt.Errorf("problem upgrading connection to WebSockets %w", err)
 	}
 
-	return &playerServerWS{conn}
+	return &playerServerWS{Conn: conn}, nil
 }
 
-func (w *playerServerWS) WaitForMsg() string {
-	_, msg, err := w.ReadMessage()
+// WaitForMsg returns the next message. Messages that break the protocol are
+// reported with an error wrapping errBadMessage, while any other error means
+// the connection is closed.
+func (w *playerServerWS) WaitForMsg() (Message, error) {
+	_, raw, err := w.ReadMessage()
 
 	if err != nil {
-		log.Printf("error handling websocket %v\n", err)
+		return Message{}, fmt.Errorf("error handling websocket %w", err)
 	}
 
-	return string(msg)
+	return parseMessage(raw)
 }
 
-func (w *playerServerWS) Write(p []byte) (n int, err error) {
-	err = w.WriteMessage(websocket.TextMessage, p)
-
+// Send sends a message of msgType carrying data.
+func (w *playerServerWS) Send(msgType MessageType, data any) error {
+	msg, err := NewMessage(msgType, data)
 	if err != nil {
+		return err
+	}
+
+	w.writeLock.Lock()
+	defer w.writeLock.Unlock()
+
+	if err := w.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return fmt.Errorf("couldn't set the write deadline: %w", err)
+	}
+
+	return w.WriteJSON(msg)
+}
+
+// WriteBlind sends alert as a blind message.
+func (w *playerServerWS) WriteBlind(alert engine.BlindAlert) error {
+	return w.Send(MsgBlind, BlindMessage{
+		Level: &alert.Level,
+		Text:  alert.Level.String(),
+		Lasts: engine.Duration(alert.Lasts),
+	})
+}
+
+// WriteState sends game as a state message, to those watching it.
+func (w *playerServerWS) WriteState(game session.Session) error {
+	return w.Send(MsgState, StateMessage{Session: game})
+}
+
+// Write sends text alerted by games that don't use WriteBlind as a blind
+// message.
+func (w *playerServerWS) Write(p []byte) (n int, err error) {
+	if err := w.Send(MsgBlind, BlindMessage{Text: strings.TrimSpace(string(p))}); err != nil {
 		return 0, err
 	}
 
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+const noRegistry = "this server keeps no player registry"
+
+type registryKey struct{}
+
+// Alias is another name for a player.
+type Alias struct {
+	Alias string `json:"alias"`
+}
+
+// Merge names who a player is merged into.
+type Merge struct {
+	Into string `json:"into"`
+}
+
+// WithPlayers records every player under the name registry keeps for them,
+// however it's spelled. Named leagues keep registries of their own if the
+// leagues can.
+func WithPlayers(registry engine.PlayerRegistry) Option {
+	return func(p *PlayerServer) {
+		p.players = registry
+	}
+}
+
+// playersFor returns the player registry of the named league a request was
+// routed to, or the server's own outside of /leagues. It's nil for servers
+// keeping none.
+func (p *PlayerServer) playersFor(ctx context.Context) engine.PlayerRegistry {
+	if registry, ok := ctx.Value(registryKey{}).(engine.PlayerRegistry); ok {
+		return registry
+	}
+
+	return p.players
+}
+
+// leaguePlayers returns the player registry of named league id, if the
+// server and its leagues keep any.
+func (p *PlayerServer) leaguePlayers(ctx context.Context, id string) (engine.PlayerRegistry, error) {
+	leagues, ok := p.leagues.(engine.RegisteredLeagues)
+	if !ok || p.players == nil {
+		return nil, nil
+	}
+
+	return leagues.Players(ctx, id)
+}
+
+// register returns the name name is recorded under, registering them if
+// they're new.
+func (p *PlayerServer) register(ctx context.Context, name string) (string, error) {
+	registry := p.playersFor(ctx)
+	if registry == nil {
+		return name, nil
+	}
+
+	player, err := registry.Register(ctx, name, p.clock.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	return player.Name, nil
+}
+
+// lookUp returns the name name is recorded under, which is name itself for
+// players nobody registered.
+func (p *PlayerServer) lookUp(ctx context.Context, name string) (string, error) {
+	registry := p.playersFor(ctx)
+	if registry == nil {
+		return name, nil
+	}
+
+	player, err := registry.FindPlayer(ctx, name)
+	if errors.Is(err, engine.ErrPlayerNotFound) {
+		return name, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return player.Name, nil
+}
+
+// participants returns the names the players of a game are recorded under,
+// registering those who are new.
+func (p *PlayerServer) participants(ctx context.Context, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	registered := make([]string, len(names))
+	for i, name := range names {
+		var err error
+		if registered[i], err = p.register(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return engine.ParseParticipants(registered)
+}
+
+// aliasesHandler returns the player of the path with their aliases on GET,
+// and gives them another on POST.
+func (p *PlayerServer) aliasesHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "aliases can only be checked with GET or added with POST", http.MethodGet, http.MethodPost) {
+		return
+	}
+
+	registry := p.playersFor(r.Context())
+	if registry == nil {
+		writeProblem(w, http.StatusNotFound, noRegistry)
+		return
+	}
+
+	name, ok := playerName(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		player, err := registry.FindPlayer(r.Context(), name)
+		if err != nil {
+			writeRegistryError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, player)
+		return
+	}
+
+	var alias Alias
+	if err := decodeJSON(r, &alias); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	player, err := registry.AddAlias(r.Context(), name, alias.Alias)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, player)
+}
+
+// mergeHandler merges the player of the path into the one named by the body,
+// along with their matches and wins, answering with who they were merged
+// into.
+func (p *PlayerServer) mergeHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "players can only be merged with POST", http.MethodPost) {
+		return
+	}
+
+	registry := p.playersFor(r.Context())
+	if registry == nil {
+		writeProblem(w, http.StatusNotFound, noRegistry)
+		return
+	}
+
+	name, ok := playerName(w, r)
+	if !ok {
+		return
+	}
+
+	var merge Merge
+	if err := decodeJSON(r, &merge); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	player, err := engine.MergePlayers(r.Context(), registry, p.storeFor(r.Context()), name, merge.Into, callerName(r.Context()), p.clock.Now().UTC())
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, player)
+}
+
+func writeRegistryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, engine.ErrBadPlayerName), errors.Is(err, engine.ErrBadCorrection):
+		writeProblem(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, engine.ErrPlayerNotFound):
+		writeProblem(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, engine.ErrNameTaken):
+		writeProblem(w, http.StatusConflict, err.Error())
+	default:
+		log.Println("couldn't look the player up: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't look the player up")
+	}
+}
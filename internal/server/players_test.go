@@ -0,0 +1,92 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/server"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestPlayerRegistry(t *testing.T) {
+	users := tests.NewStubUserStore(t)
+	at := time.Date(2026, time.May, 9, 21, 0, 0, 0, time.UTC)
+	store := tests.StubPlayerStore{
+		Scores: map[string]int{"Pepper": 2, "Pep": 1},
+		League: engine.League{{Name: "Pepper", Wins: 2}, {Name: "Pep", Wins: 1}},
+	}
+	players := tests.StubPlayerRegistry{Players: engine.Registry{
+		{ID: "pepper", Name: "Pepper", CreatedAt: at},
+		{ID: "pep", Name: "Pep", CreatedAt: at},
+	}}
+	playerServer := mustMakePlayerServer(t, &store, tests.DummyGame, server.WithUsers(users), server.WithPlayers(&players))
+	admin := mustLogIn(t, playerServer, "admin", "password")
+	scorekeeper := mustLogIn(t, playerServer, "scorekeeper", "password")
+
+	t.Run("it records wins under the registered name", func(t *testing.T) {
+		request := newPostWinRequest("PEPPER")
+		request.AddCookie(scorekeeper)
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		tests.AssertStatus(t, response, http.StatusAccepted)
+		tests.AssertPlayerWin(t, &store, "Pepper")
+	})
+
+	t.Run("it shows the score of any spelling of a name", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newGetScoreRequest("pepper"))
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		tests.AssertResponseBody(t, response.Body.String(), "2")
+	})
+
+	t.Run("it refuses aliases of other players", func(t *testing.T) {
+		request := newCorrectionRequest("/players/Pepper/aliases", `{"alias": "pep"}`)
+		request.AddCookie(admin)
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		assertProblem(t, response, http.StatusConflict)
+	})
+
+	t.Run("only admins merge players", func(t *testing.T) {
+		request := newCorrectionRequest("/players/Pep/merge", `{"into": "Pepper"}`)
+		request.AddCookie(scorekeeper)
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		assertProblem(t, response, http.StatusForbidden)
+	})
+
+	t.Run("it merges players along with their wins", func(t *testing.T) {
+		request := newCorrectionRequest("/players/Pep/merge", `{"into": "Pepper"}`)
+		request.AddCookie(admin)
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, request)
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		var player engine.RegisteredPlayer
+		if err := json.NewDecoder(response.Body).Decode(&player); err != nil {
+			t.Fatalf("couldn't decode the player: %v", err)
+		}
+		if player.Name != "Pepper" || !player.Known("Pep") {
+			t.Errorf("got player %+v, want Pepper known as Pep", player)
+		}
+
+		tests.AssertPlayerScore(t, &store, "Pepper", 3)
+		tests.AssertPlayerScore(t, &store, "Pep", 0)
+		if len(store.Audit) != 1 || store.Audit[0].Action != engine.ActionMerge || store.Audit[0].By != "admin" {
+			t.Errorf("got audit log %+v, want the merge by admin", store.Audit)
+		}
+	})
+}
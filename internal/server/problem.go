@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// Problem is an error response, as described by RFC 7807. Every problem is of
+// the about:blank type, so its title is the text of its status.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+
+	w.Header().Set("content-type", ProblemContentType)
+	w.Header().Set("x-content-type-options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Println("couldn't encode the problem: ", err)
+	}
+}
+
+// allowMethods answers with a 405 problem unless r uses one of methods,
+// reporting whether it did.
+func allowMethods(w http.ResponseWriter, r *http.Request, detail string, methods ...string) bool {
+	if slices.Contains(methods, r.Method) {
+		return true
+	}
+
+	methodNotAllowed(w, detail, methods...)
+	return false
+}
+
+// methodNotAllowed answers with a 405 problem allowing methods.
+func methodNotAllowed(w http.ResponseWriter, detail string, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeProblem(w, http.StatusMethodNotAllowed, detail)
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/session"
+)
+
+// ProtocolVersion is the version of the messages exchanged on /ws. Messages
+// of any other version are refused.
+const ProtocolVersion = 1
+
+// maxPlayers is the most players a game of hold'em can seat, dealing two cards
+// to each and still leaving a deck for the board.
+const maxPlayers = 23
+
+type MessageType string
+
+const (
+	// MsgStart starts the game, sent by the client with a StartMessage.
+	MsgStart MessageType = "start"
+	// MsgFinish declares the winner, sent by the client with a FinishMessage.
+	MsgFinish MessageType = "finish"
+	// MsgBlind alerts a new level of blinds, sent by the server with a
+	// BlindMessage.
+	MsgBlind MessageType = "blind"
+	// MsgError reports a message that couldn't be handled, sent by the server
+	// with an ErrorMessage.
+	MsgError MessageType = "error"
+	// MsgState describes the game, sent by the server with a StateMessage
+	// whenever the game changes. Only the player gets a token with it.
+	MsgState MessageType = "state"
+	// MsgPing checks the connection, sent by the client without data and
+	// answered with another ping.
+	MsgPing MessageType = "ping"
+)
+
+// Message is the envelope of everything sent on /ws, with Data depending on
+// its Type.
+type Message struct {
+	Version int             `json:"v"`
+	Type    MessageType     `json:"type"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type StartMessage struct {
+	// Players is how many play, which can be left out when they're named.
+	Players int `json:"players,omitempty"`
+	// Participants names the players, so only they can win.
+	Participants []string `json:"participants,omitempty"`
+	// Blinds names the blind structure preset, the standard one if empty.
+	Blinds string `json:"blinds,omitempty"`
+}
+
+type FinishMessage struct {
+	Winner string `json:"winner"`
+	// Confirm records the win of a winner who isn't a participant of the game.
+	Confirm bool `json:"confirm,omitempty"`
+}
+
+type BlindMessage struct {
+	// Level is missing from alerts that were only written as Text.
+	Level *engine.BlindLevel `json:"level,omitempty"`
+	Text  string             `json:"text"`
+	// Lasts is how long until the next level, missing for the last one and
+	// when it isn't known.
+	Lasts engine.Duration `json:"lasts,omitempty"`
+}
+
+type StateMessage struct {
+	session.Session
+	// Token lets the player reconnect to the game with /ws?game=id&token=token
+	// if the connection is lost.
+	Token string `json:"token,omitempty"`
+}
+
+type ErrorMessage struct {
+	Message string `json:"message"`
+	// Confirm is set when the winner didn't play, so the finish message can be
+	// sent again with Confirm to record the win anyway.
+	Confirm bool `json:"confirm,omitempty"`
+}
+
+var errBadMessage = errors.New("bad message")
+
+// NewMessage wraps data in an envelope of the current version.
+func NewMessage(msgType MessageType, data any) (Message, error) {
+	msg := Message{Version: ProtocolVersion, Type: msgType}
+
+	if data == nil {
+		return msg, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Message{}, fmt.Errorf("couldn't encode the %s message: %w", msgType, err)
+	}
+
+	msg.Data = encoded
+	return msg, nil
+}
+
+// parseMessage decodes and checks the envelope of a message from a client.
+func parseMessage(raw []byte) (Message, error) {
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return Message{}, fmt.Errorf("%w, want a JSON object: %v", errBadMessage, err)
+	}
+
+	if msg.Version != ProtocolVersion {
+		return Message{}, fmt.Errorf("%w, unsupported protocol version %d, want %d", errBadMessage, msg.Version, ProtocolVersion)
+	}
+
+	switch msg.Type {
+	case MsgStart, MsgFinish, MsgPing:
+		return msg, nil
+	case "":
+		return Message{}, fmt.Errorf("%w, it has no type", errBadMessage)
+	default:
+		return Message{}, fmt.Errorf("%w, clients can't send %q messages", errBadMessage, msg.Type)
+	}
+}
+
+// decodeData decodes the data of msg into v, refusing fields it doesn't know.
+func decodeData(msg Message, v any) error {
+	if len(msg.Data) == 0 {
+		return fmt.Errorf("%w, %s messages need data", errBadMessage, msg.Type)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(msg.Data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w, couldn't read the %s data: %v", errBadMessage, msg.Type, err)
+	}
+
+	return nil
+}
+
+func (m StartMessage) validate() error {
+	players := m.Players
+	if len(m.Participants) > 0 {
+		if m.Players != 0 && m.Players != len(m.Participants) {
+			return fmt.Errorf("%w, %d players are named but %d play", errBadMessage, len(m.Participants), m.Players)
+		}
+		players = len(m.Participants)
+	}
+
+	if players < 2 {
+		return fmt.Errorf("%w, a game needs at least 2 players, got %d", errBadMessage, players)
+	}
+
+	if players > maxPlayers {
+		return fmt.Errorf("%w, a game seats at most %d players, got %d", errBadMessage, maxPlayers, players)
+	}
+
+	return nil
+}
+
+func (m FinishMessage) validate() error {
+	if strings.TrimSpace(m.Winner) == "" {
+		return fmt.Errorf("%w, the winner needs a name", errBadMessage)
+	}
+
+	return nil
+}
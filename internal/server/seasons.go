@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// standings returns the current league, or the archived one of the season
+// with id along with the season itself.
+func (p *PlayerServer) standings(ctx context.Context, id string) (engine.League, *engine.Season, error) {
+	if id == "" {
+		league, err := p.storeFor(ctx).GetLeague(ctx)
+		return league, nil, err
+	}
+
+	seasons, err := p.storeFor(ctx).GetSeasons(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	season := engine.FindSeason(seasons, id)
+	if season == nil {
+		return nil, nil, fmt.Errorf("%w: %s", engine.ErrSeasonNotFound, id)
+	}
+
+	return season.Standings, season, nil
+}
+
+func (p *PlayerServer) seasonsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "seasons can only be listed with GET", http.MethodGet) {
+		return
+	}
+
+	seasons, err := p.storeFor(r.Context()).GetSeasons(r.Context())
+	if err != nil {
+		log.Println("couldn't get the seasons: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't get the seasons")
+		return
+	}
+
+	if seasons == nil {
+		seasons = []engine.Season{}
+	}
+
+	w.Header().Set("content-type", JSONContentType)
+	if err := json.NewEncoder(w).Encode(seasons); err != nil {
+		log.Println("couldn't encode the json: ", err)
+	}
+}
+
+// closeSeason archives the current league as the season named in the path
+// and starts the league over.
+func (p *PlayerServer) closeSeason(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "seasons can only be closed with POST", http.MethodPost) {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/seasons/")
+
+	season, err := p.storeFor(r.Context()).CloseSeason(r.Context(), id, time.Now().UTC())
+	if err != nil {
+		writeSeasonError(w, err)
+		return
+	}
+
+	w.Header().Set("content-type", JSONContentType)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(season); err != nil {
+		log.Println("couldn't encode the json: ", err)
+	}
+}
+
+func writeSeasonError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, engine.ErrBadSeasonID):
+		writeProblem(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, engine.ErrSeasonExists):
+		writeProblem(w, http.StatusConflict, err.Error())
+	default:
+		log.Println("couldn't close the season: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't close the season")
+	}
+}
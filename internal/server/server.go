@@ -1,17 +1,20 @@
 package server
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
-	"strconv"
+	"slices"
 	"strings"
 
 	"github.com/gorilla/websocket"
 	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/session"
 )
 
 //go:embed game.html
@@ -23,14 +26,56 @@ var wsUpgrader = websocket.Upgrader{
 
 const JSONContentType = "application/json"
 
+const (
+	SortByWins   = "wins"
+	SortByRating = "rating"
+)
+
 type PlayerServer struct {
 	store engine.PlayerStore
 	http.Handler
 	template *template.Template
-	game     engine.Game
+	games    *session.Manager
+	clock    engine.Clock
+	rating   engine.Elo
+	leagues  engine.Leagues
+	blinds   engine.BlindPresets
+	keys     engine.KeyStore
+	users    engine.UserStore
+	players  engine.PlayerRegistry
+	logins   *logins
+	// privateReads makes reading a league take a viewer too.
+	privateReads bool
+}
+
+// Option configures the optional parts of a PlayerServer.
+type Option func(*PlayerServer)
+
+// WithLeagues serves the named leagues under /leagues next to the league
+// of store.
+func WithLeagues(leagues engine.Leagues) Option {
+	return func(p *PlayerServer) {
+		p.leagues = leagues
+	}
+}
+
+// WithBlindPresets lets games started from /game pick their blinds from
+// presets instead of only the standard structure.
+func WithBlindPresets(presets engine.BlindPresets) Option {
+	return func(p *PlayerServer) {
+		p.blinds = presets
+	}
+}
+
+// WithClock times games with clock instead of the system clock.
+func WithClock(clock engine.Clock) Option {
+	return func(p *PlayerServer) {
+		p.clock = clock
+	}
 }
 
-func NewPlayerServer(store engine.PlayerStore, game engine.Game) (*PlayerServer, error) {
+// NewPlayerServer serves store, playing every game with one made by newGame.
+func NewPlayerServer(store engine.PlayerStore, newGame func() engine.Game, opts ...Option) (*PlayerServer, error) {
 	p := new(PlayerServer)
 
 	tmpl, err := template.New("game.html").Parse(gameHTML)
@@ -38,80 +83,421 @@ func NewPlayerServer(store engine.PlayerStore, game engine.Game) (*PlayerServer,
 		return nil, fmt.Errorf("problem loading template %s", err.Error())
 	}
 
-	p.game = game
 	p.template = tmpl
 	p.store = store
+	p.rating = engine.NewElo()
+	p.blinds = engine.DefaultBlindPresets()
+	p.clock = engine.SystemClock{}
+	p.logins = newLogins()
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.games = session.NewManager(newGame, p.clock)
 
 	router := http.NewServeMux()
 
-	router.Handle("/league", http.HandlerFunc(p.leagueHandler))
-	router.Handle("/players/", http.HandlerFunc(p.playersHandler))
+	p.handleLeague(router)
+	router.Handle("/leagues", http.HandlerFunc(p.leaguesHandler))
+	router.Handle("/leagues/{id}", http.HandlerFunc(p.createLeague))
+	router.Handle("/leagues/{league}/", p.inLeague(p.handleLeague(http.NewServeMux())))
+	router.Handle("/games", http.HandlerFunc(p.gamesHandler))
+	router.Handle("/games/{id}", http.HandlerFunc(p.gameHandler))
+	router.Handle("/games/{id}/{action}", http.HandlerFunc(p.changeGame))
 	router.Handle("/game", http.HandlerFunc(p.playGame))
 	router.Handle("/ws", http.HandlerFunc(p.webSocket))
+	router.Handle("/login", http.HandlerFunc(p.loginHandler))
+	router.Handle("/logout", http.HandlerFunc(p.logoutHandler))
+	router.Handle(APIPrefix+"/", p.handleAPI())
 	router.Handle("/", http.HandlerFunc(p.pageHandler))
 
-	p.Handler = router
+	p.Handler = p.guard(router)
 
 	return p, nil
 }
 
-func (p *PlayerServer) pageHandler(w http.ResponseWriter, _ *http.Request) {
+// handleLeague routes the requests about a single league, be it the one of
+// the server's store or one of the named leagues.
+func (p *PlayerServer) handleLeague(router *http.ServeMux) *http.ServeMux {
+	router.Handle("/league", http.HandlerFunc(p.leagueHandler))
+	router.Handle("/league/stream", http.HandlerFunc(p.leagueStream))
+	router.Handle("/players/", http.HandlerFunc(p.playersHandler))
+	router.Handle("/players/{name}/aliases", http.HandlerFunc(p.aliasesHandler))
+	router.Handle("/players/{name}/merge", http.HandlerFunc(p.mergeHandler))
+	router.Handle("/players/{name}/stats", http.HandlerFunc(p.statsHandler))
+	router.Handle("/seasons", http.HandlerFunc(p.seasonsHandler))
+	router.Handle("/seasons/", http.HandlerFunc(p.closeSeason))
+	router.Handle("/corrections", http.HandlerFunc(p.correctionsHandler))
+	router.Handle("/audit", http.HandlerFunc(p.auditHandler))
+
+	return router
+}
+
+func (p *PlayerServer) pageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeProblem(w, http.StatusNotFound, fmt.Sprintf("there's nothing at %s", r.URL.Path))
+		return
+	}
+	if !allowMethods(w, r, "the greeting can only be read with GET", http.MethodGet) {
+		return
+	}
+
 	if _, err := fmt.Fprint(
 		w,
 		"Hello, run cli tool to record score!\n",
-		"/players/$playername to check a player\n",
-		"/league to check the league\n",
-		"/game to check the game\n",
+		"/players/$playername to check a player, /players/$playername/stats for how they have done\n",
+		"/league to check the league, /league?sort=rating to rank it by skill\n",
+		"/seasons to check past seasons, /league?season=$season for their standings\n",
+		"/audit to check the corrections made to the results\n",
+		"/leagues to list the named leagues, /leagues/$league/league to check one\n",
+		"/game to play a game, /games to check the games being played\n",
+		"/api/v2 for the JSON API, described by /api/v2/openapi.json\n",
 	); err != nil {
 		log.Println("couldn't print the greeting: ", err)
 	}
 }
 
-func (p *PlayerServer) leagueHandler(w http.ResponseWriter, _ *http.Request) {
+func (p *PlayerServer) leagueHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "the league can only be checked with GET", http.MethodGet) {
+		return
+	}
+
+	league, err := p.rankedLeague(r.Context(), r.URL.Query().Get("season"), r.URL.Query().Get("sort"))
+	if err != nil {
+		writeLeagueError(w, err)
+		return
+	}
+
 	w.Header().Set("content-type", JSONContentType)
-	if err := json.NewEncoder(w).Encode(p.store.GetLeague()); err != nil {
+	if err := json.NewEncoder(w).Encode(league); err != nil {
 		log.Println("couldn't encode the json: ", err)
 	}
 }
 
+var errBadSort = errors.New("can't sort the league")
+
+// rankedLeague returns the standings of the season with id, the current ones
+// if it's empty, ordered by sortBy.
+func (p *PlayerServer) rankedLeague(ctx context.Context, id, sortBy string) (engine.League, error) {
+	if sortBy != "" && sortBy != SortByWins && sortBy != SortByRating {
+		return nil, fmt.Errorf("%w by %q, only by %s or %s", errBadSort, sortBy, SortByWins, SortByRating)
+	}
+
+	league, season, err := p.standings(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sortBy != SortByRating {
+		return league, nil
+	}
+
+	matches, err := p.storeFor(ctx).GetMatches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get the matches: %w", err)
+	}
+
+	// a past season is ranked by the ratings players had when it ended
+	if season != nil {
+		matches = slices.DeleteFunc(matches, func(m engine.Match) bool {
+			return m.FinishedAt.After(season.EndedAt)
+		})
+	}
+
+	return league.Rated(p.rating.Rate(matches), p.rating.Initial), nil
+}
+
+func writeLeagueError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errBadSort):
+		writeProblem(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, engine.ErrSeasonNotFound):
+		writeProblem(w, http.StatusNotFound, err.Error())
+	default:
+		log.Println("couldn't get the league: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't get the league")
+	}
+}
+
 func (p *PlayerServer) playersHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "players can only be checked with GET or credited with a win with POST", http.MethodGet, http.MethodPost) {
+		return
+	}
+
 	player := strings.TrimPrefix(r.URL.Path, "/players/")
+	if err := engine.ValidatePlayerName(player); err != nil {
+		writeProblem(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	switch r.Method {
 	case http.MethodPost:
-		p.processWin(w, player)
+		p.processWin(w, r, player)
 	case http.MethodGet:
-		p.showScore(w, player)
+		p.showScore(w, r, player)
 	}
+}
 
+// gamePage is what the game page is rendered from.
+type gamePage struct {
+	Blinds        []string
+	DefaultBlinds string
+	// Watch is the id of the game the page only shows, from the watch query
+	// parameter.
+	Watch string
+	// Login is whether players have to log in to play.
+	Login bool
 }
 
-func (p *PlayerServer) playGame(w http.ResponseWriter, _ *http.Request) {
-	if err := p.template.Execute(w, nil); err != nil {
+func (p *PlayerServer) playGame(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "the game page can only be loaded with GET", http.MethodGet) {
+		return
+	}
+
+	page := gamePage{
+		Blinds:        p.blinds.Names(),
+		DefaultBlinds: engine.StandardBlindsName,
+		Watch:         r.URL.Query().Get("watch"),
+		Login:         p.users != nil,
+	}
+
+	if err := p.template.Execute(w, page); err != nil {
 		log.Println("couldn't execute the template: ", err)
 	}
 }
 
+// webSocket plays the game named by the game query parameter, or a new one
+// without it, speaking the protocol of protocol.go. Playing takes a
+// scorekeeper, like creating a game on /games does. Messages that can't be
+// handled are answered with an error and the game carries on. With the watch
+// query parameter, the game is only watched, which anyone may do.
 func (p *PlayerServer) webSocket(w http.ResponseWriter, r *http.Request) {
-	ws := newPlayerServerWS(w, r)
+	id, token := r.URL.Query().Get("game"), r.URL.Query().Get("token")
+	if r.URL.Query().Has("watch") {
+		p.watchGame(w, r, id)
+		return
+	}
+
+	if !p.allows(r.Context(), engine.RoleScorekeeper) {
+		refuse(w, r, engine.RoleScorekeeper)
+		return
+	}
+
+	switch {
+	case id == "":
+		// the game is hosted once the websocket is open
+	case token != "":
+		// a player coming back to the game they were playing
+		if err := p.games.CheckToken(id, token); err != nil {
+			writeGameError(w, err)
+			return
+		}
+	case !p.gameWaiting(w, id):
+		return
+	}
+
+	ws, err := newPlayerServerWS(w, r)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	defer func() {
+		if err := ws.Close(); err != nil {
+			log.Println("couldn't close the websocket: ", err)
+		}
+	}()
 
-	numberOfPlayersMsg := ws.WaitForMsg()
-	numberOfPlayers, err := strconv.Atoi(numberOfPlayersMsg)
+	switch {
+	case token != "":
+		p.rejoin(ws, id, token)
+	case id == "":
+		game := p.games.Host(ws)
+		id = game.ID
+		p.sendState(ws, game, true)
+	default:
+		p.join(ws, id)
+	}
+
+	for {
+		msg, err := ws.WaitForMsg()
+		if err != nil && !errors.Is(err, errBadMessage) {
+			// the player may come back with their token
+			log.Println("the websocket closed: ", err)
+			p.disconnect(ws, id)
+			return
+		}
+
+		finished := false
+		if err == nil {
+			finished, err = p.handleMessage(r.Context(), ws, id, msg)
+		}
+
+		if err != nil {
+			log.Println("couldn't handle the message: ", err)
+			p.send(ws, MsgError, ErrorMessage{Message: explain(err), Confirm: errors.Is(err, engine.ErrNotParticipant)})
+			continue
+		}
+
+		if finished {
+			return
+		}
+	}
+}
+
+// handleMessage does what msg asks of game id, reporting whether the game is
+// over.
+func (p *PlayerServer) handleMessage(ctx context.Context, ws *playerServerWS, id string, msg Message) (bool, error) {
+	switch msg.Type {
+	case MsgPing:
+		p.send(ws, MsgPing, nil)
+		return false, nil
+
+	case MsgStart:
+		if !p.allows(ctx, engine.RoleScorekeeper) {
+			return false, errNotAllowed
+		}
+
+		var start StartMessage
+		if err := decodeData(msg, &start); err != nil {
+			return false, err
+		}
+		if err := start.validate(); err != nil {
+			return false, err
+		}
+
+		blinds, err := p.blinds.Get(start.Blinds)
+		if err != nil {
+			return false, fmt.Errorf("%w, %v", errBadMessage, err)
+		}
+
+		participants, err := p.participants(ctx, start.Participants)
+		if errors.Is(err, engine.ErrBadPlayerName) {
+			return false, fmt.Errorf("%w, %v", errBadMessage, err)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		game, err := p.games.Start(id, max(start.Players, len(participants)), participants, blinds, ws)
+		if err != nil {
+			return false, err
+		}
+
+		p.sendState(ws, game, true)
+		return false, nil
+
+	case MsgFinish:
+		if !p.allows(ctx, engine.RoleScorekeeper) {
+			return false, errNotAllowed
+		}
+
+		var finish FinishMessage
+		if err := decodeData(msg, &finish); err != nil {
+			return false, err
+		}
+		if err := finish.validate(); err != nil {
+			return false, err
+		}
+
+		winner, err := p.register(ctx, finish.Winner)
+		if errors.Is(err, engine.ErrBadPlayerName) {
+			return false, fmt.Errorf("%w, %v", errBadMessage, err)
+		}
+		if err != nil {
+			return false, err
+		}
+
+		game, err := p.games.Finish(ctx, id, winner, finish.Confirm)
+		if err != nil {
+			return false, err
+		}
+
+		// nobody reconnects to a finished game
+		p.sendState(ws, game, false)
+		return true, nil
+	}
+
+	return false, fmt.Errorf("%w, can't handle %q messages", errBadMessage, msg.Type)
+}
+
+// rejoin passes the alerts of game id on to ws again, catching the player up
+// on the game and its current blinds.
+func (p *PlayerServer) rejoin(ws *playerServerWS, id, token string) {
+	game, err := p.games.Reconnect(id, token, ws)
+	if err != nil {
+		log.Println("couldn't reconnect to the game: ", err)
+		p.send(ws, MsgError, ErrorMessage{Message: err.Error()})
+		return
+	}
+
+	p.sendState(ws, game, true)
+	if game.Level != nil {
+		p.send(ws, MsgBlind, BlindMessage{Level: game.Level, Text: game.Level.String()})
+	}
+}
+
+// join has ws play the waiting game id, unless someone else already does.
+// Only the player gets the token of the game.
+func (p *PlayerServer) join(ws *playerServerWS, id string) {
+	game, joined, err := p.games.Join(id, ws)
 	if err != nil {
-		log.Println("couldn't convert the numberOfPlayers: ", err)
+		log.Println("couldn't join the game: ", err)
+		p.send(ws, MsgError, ErrorMessage{Message: err.Error()})
+		return
+	}
+
+	p.sendState(ws, game, joined)
+}
+
+func (p *PlayerServer) disconnect(ws *playerServerWS, id string) {
+	if _, err := p.games.Disconnect(id, ws); err != nil {
+		log.Println("couldn't leave the game: ", err)
+	}
+}
+
+// sendState describes game to ws, with the token to reconnect with if ws is
+// its player's.
+func (p *PlayerServer) sendState(ws *playerServerWS, game session.Session, player bool) {
+	var token string
+	if player {
+		var err error
+		if token, err = p.games.Token(game.ID); err != nil {
+			log.Println("couldn't get the token of the game: ", err)
+		}
 	}
 
-	p.game.Start(numberOfPlayers, ws)
+	p.send(ws, MsgState, StateMessage{Session: game, Token: token})
+}
+
+func (p *PlayerServer) send(ws *playerServerWS, msgType MessageType, data any) {
+	if err := ws.Send(msgType, data); err != nil {
+		log.Printf("couldn't send the %s message: %v", msgType, err)
+	}
+}
 
-	winner := ws.WaitForMsg()
-	p.game.Finish(winner)
+// explain tells the client what went wrong with its message, without
+// giving away the details of failures on the server's side.
+func explain(err error) string {
+	switch {
+	case errors.Is(err, errBadMessage), errors.Is(err, session.ErrWrongState), errors.Is(err, errNotAllowed), errors.Is(err, engine.ErrNotParticipant):
+		return err.Error()
+	default:
+		return "couldn't record the winner, please try again"
+	}
 }
 
-func (p *PlayerServer) showScore(w http.ResponseWriter, player string) {
-	score := p.store.GetPlayerScore(player)
+func (p *PlayerServer) showScore(w http.ResponseWriter, r *http.Request, player string) {
+	player, err := p.lookUp(r.Context(), player)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
 
-	if score == 0 {
-		w.WriteHeader(http.StatusNotFound)
+	score, err := p.score(r.Context(), player)
+	if err != nil {
+		writeScoreError(w, err)
+		return
 	}
 
 	if _, err := fmt.Fprint(w, score); err != nil {
@@ -119,7 +505,52 @@ func (p *PlayerServer) showScore(w http.ResponseWriter, player string) {
 	}
 }
 
-func (p *PlayerServer) processWin(w http.ResponseWriter, player string) {
-	p.store.RecordWin(player)
+var errNeverPlayed = errors.New("hasn't played yet")
+
+// score returns the wins of player, telling those without wins apart from
+// those who never played with errNeverPlayed.
+func (p *PlayerServer) score(ctx context.Context, player string) (int, error) {
+	store := p.storeFor(ctx)
+
+	score, err := store.GetPlayerScore(ctx, player)
+	if err != nil || score > 0 {
+		return score, err
+	}
+
+	matches, err := store.GetMatches(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't get the matches: %w", err)
+	}
+
+	if !engine.Played(matches, player) {
+		return 0, fmt.Errorf("%s %w", player, errNeverPlayed)
+	}
+
+	return 0, nil
+}
+
+func writeScoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errNeverPlayed) {
+		writeProblem(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	log.Println("couldn't get the score: ", err)
+	writeProblem(w, http.StatusInternalServerError, "couldn't get the score")
+}
+
+func (p *PlayerServer) processWin(w http.ResponseWriter, r *http.Request, player string) {
+	player, err := p.register(r.Context(), player)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	if err := p.storeFor(r.Context()).RecordWin(r.Context(), player); err != nil {
+		log.Println("couldn't record the win: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't record the win")
+		return
+	}
+
 	w.WriteHeader(http.StatusAccepted)
 }
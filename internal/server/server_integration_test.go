@@ -19,7 +19,7 @@ func TestRecordingWinsAndRetrievingThem(t *testing.T) {
 	store, err := filesystem.NewPlayerStore(database)
 	tests.AssertNoError(t, err)
 
-	server, _ := server.NewPlayerServer(store, tests.DummyGame)
+	server, _ := server.NewPlayerServer(store, tests.NewGame(tests.DummyGame))
 
 	player := "Pepper"
 
@@ -1,11 +1,14 @@
 package server_test
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +16,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/oblassov/game-score-server/internal/engine"
 	"github.com/oblassov/game-score-server/internal/server"
+	"github.com/oblassov/game-score-server/internal/session"
 	"github.com/oblassov/game-score-server/tests"
 )
 
@@ -22,6 +26,7 @@ func TestGETPlayers(t *testing.T) {
 			"Pepper": 20,
 			"Floyd":  10,
 		},
+		Matches: []engine.Match{{Winner: "Pepper", Participants: []string{"Pepper", "Chris"}}},
 	}
 
 	server := mustMakePlayerServer(t, &store, tests.DummyGame)
@@ -32,7 +37,17 @@ func TestGETPlayers(t *testing.T) {
 
 		server.ServeHTTP(response, request)
 
-		tests.AssertStatus(t, response, http.StatusNotFound)
+		assertProblem(t, response, http.StatusNotFound)
+	})
+
+	t.Run("returns 0 for players who played without winning", func(t *testing.T) {
+		request := newGetScoreRequest("Chris")
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, request)
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		tests.AssertResponseBody(t, response.Body.String(), "0")
 	})
 
 	t.Run("returns Pepper's score", func(t *testing.T) {
@@ -56,6 +71,55 @@ func TestGETPlayers(t *testing.T) {
 	})
 }
 
+func TestErrors(t *testing.T) {
+	playerServer := mustMakePlayerServer(t, &tests.StubPlayerStore{}, tests.DummyGame)
+
+	t.Run("it refuses methods it doesn't handle with the ones it does", func(t *testing.T) {
+		cases := []struct {
+			method, path, allow string
+		}{
+			{http.MethodPut, "/players/Pepper", "GET, POST"},
+			{http.MethodDelete, "/players/Pepper", "GET, POST"},
+			{http.MethodPost, "/league", "GET"},
+			{http.MethodPut, "/seasons", "GET"},
+			{http.MethodGet, "/seasons/2026", "POST"},
+			{http.MethodDelete, "/games", "GET, POST"},
+			{http.MethodPost, "/", "GET"},
+		}
+
+		for _, c := range cases {
+			t.Run(c.method+" "+c.path, func(t *testing.T) {
+				response := httptest.NewRecorder()
+
+				playerServer.ServeHTTP(response, newRequest(c.method, c.path))
+
+				assertProblem(t, response, http.StatusMethodNotAllowed)
+				if got := response.Header().Get("Allow"); got != c.allow {
+					t.Errorf("got Allow %q, want %q", got, c.allow)
+				}
+			})
+		}
+	})
+
+	t.Run("it refuses bad player names", func(t *testing.T) {
+		for _, path := range []string{"/players/", "/players/%20Pepper", "/players/a%2Fb", "/players/" + strings.Repeat("x", 65)} {
+			response := httptest.NewRecorder()
+
+			playerServer.ServeHTTP(response, newRequest(http.MethodPost, path))
+
+			assertProblem(t, response, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("it returns 404 for unknown paths", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/nowhere"))
+
+		assertProblem(t, response, http.StatusNotFound)
+	})
+}
+
 func TestLeague(t *testing.T) {
 
 	t.Run("it returns the league table as JSON", func(t *testing.T) {
@@ -82,6 +146,436 @@ func TestLeague(t *testing.T) {
 
 }
 
+func TestLeagueStream(t *testing.T) {
+	t.Run("it streams the league every time a win is recorded", func(t *testing.T) {
+		store := &tests.StubPlayerStore{League: engine.League{{Name: "Cleo", Wins: 1}}}
+		testServer := httptest.NewServer(mustMakePlayerServer(t, store, tests.DummyGame))
+		defer testServer.Close()
+
+		response, err := http.Get(testServer.URL + "/league/stream")
+		tests.AssertNoError(t, err)
+		defer response.Body.Close()
+
+		if got := response.Header.Get("content-type"); got != "text/event-stream" {
+			t.Errorf("got content-type %q, want text/event-stream", got)
+		}
+		events := bufio.NewReader(response.Body)
+		tests.AssertLeague(t, readLeagueEvent(t, events), store.League)
+
+		store.League = engine.League{{Name: "Cleo", Wins: 1}, {Name: "Pepper", Wins: 1}}
+		posted, err := http.Post(testServer.URL+"/players/Pepper", "", nil)
+		tests.AssertNoError(t, err)
+		posted.Body.Close()
+
+		tests.AssertLeague(t, readLeagueEvent(t, events), store.League)
+	})
+
+	t.Run("it returns 501 for stores that can't tell about changes", func(t *testing.T) {
+		store := struct{ engine.PlayerStore }{&tests.StubPlayerStore{}}
+		playerServer := mustMakePlayerServer(t, store, tests.DummyGame)
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/league/stream"))
+
+		tests.AssertStatus(t, response, http.StatusNotImplemented)
+	})
+}
+
+func readLeagueEvent(t testing.TB, events *bufio.Reader) (league engine.League) {
+	t.Helper()
+
+	var event, data string
+	for {
+		line, err := events.ReadString('\n')
+		if err != nil {
+			t.Fatalf("couldn't read the stream: %v", err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			if event != "league" {
+				t.Fatalf("got a %q event, want a league", event)
+			}
+			if err := json.Unmarshal([]byte(data), &league); err != nil {
+				t.Fatalf("couldn't decode the league %s: %v", data, err)
+			}
+			return league
+		}
+	}
+}
+
+func TestLeagueByRating(t *testing.T) {
+	store := tests.StubPlayerStore{
+		League: engine.League{{Name: "Ruth", Wins: 2}, {Name: "Cleo", Wins: 1}},
+		Matches: []engine.Match{
+			{ID: "1", Winner: "Cleo", FinishingOrder: []string{"Cleo", "Chris"}},
+		},
+	}
+	server := mustMakePlayerServer(t, &store, tests.DummyGame)
+
+	t.Run("it ranks the players of the league by rating", func(t *testing.T) {
+		request := newLeagueRequestSortedBy("rating")
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, request)
+
+		got := getLeagueFromResponse(t, response.Body)
+		want := engine.League{
+			{Name: "Cleo", Wins: 1, Rating: 1516},
+			{Name: "Ruth", Wins: 2, Rating: 1500},
+		}
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		tests.AssertLeague(t, got, want)
+	})
+
+	t.Run("it rejects unknown orderings", func(t *testing.T) {
+		request := newLeagueRequestSortedBy("luck")
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, request)
+
+		tests.AssertStatus(t, response, http.StatusBadRequest)
+	})
+}
+
+func TestSeasons(t *testing.T) {
+	store := tests.StubPlayerStore{
+		League: engine.League{{Name: "Cleo", Wins: 3}},
+	}
+	server := mustMakePlayerServer(t, &store, tests.DummyGame)
+
+	t.Run("it closes the season on POST", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodPost, "/seasons/2026-Q3"))
+
+		tests.AssertStatus(t, response, http.StatusCreated)
+		tests.AssertLeague(t, store.League, nil)
+	})
+
+	for _, path := range []string{"/seasons/", "/seasons/spring%202026", "/api/v2/seasons/spring%202026"} {
+		t.Run("it refuses bad season ids on "+path, func(t *testing.T) {
+			response := httptest.NewRecorder()
+
+			server.ServeHTTP(response, newRequest(http.MethodPost, path))
+
+			assertProblem(t, response, http.StatusBadRequest)
+		})
+	}
+
+	t.Run("it refuses to close a season twice", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodPost, "/seasons/2026-Q3"))
+
+		tests.AssertStatus(t, response, http.StatusConflict)
+	})
+
+	t.Run("it returns the standings of a past season", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodGet, "/league?season=2026-Q3"))
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		tests.AssertLeague(t, getLeagueFromResponse(t, response.Body), engine.League{{Name: "Cleo", Wins: 3}})
+	})
+
+	t.Run("it returns 404 for an unknown season", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodGet, "/league?season=1999-Q1"))
+
+		tests.AssertStatus(t, response, http.StatusNotFound)
+	})
+
+	t.Run("it lists the seasons", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodGet, "/seasons"))
+
+		var seasons []engine.Season
+		if err := json.NewDecoder(response.Body).Decode(&seasons); err != nil {
+			t.Fatalf("couldn't decode the seasons: %v", err)
+		}
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		if len(seasons) != 1 || seasons[0].ID != "2026-Q3" {
+			t.Errorf("got seasons %+v, want just 2026-Q3", seasons)
+		}
+	})
+}
+
+func TestLeagues(t *testing.T) {
+	leagues := tests.StubLeagues{
+		Stores: map[string]*tests.StubPlayerStore{
+			"tuesday": {
+				Scores: map[string]int{"Pepper": 2},
+				League: engine.League{{Name: "Pepper", Wins: 2}},
+			},
+		},
+	}
+	store := tests.StubPlayerStore{Scores: map[string]int{}}
+	server := mustMakePlayerServer(t, &store, tests.DummyGame, server.WithLeagues(&leagues))
+
+	t.Run("it creates a league on POST", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodPost, "/leagues/friday"))
+
+		tests.AssertStatus(t, response, http.StatusCreated)
+	})
+
+	t.Run("it refuses to create a league twice", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodPost, "/leagues/friday"))
+
+		tests.AssertStatus(t, response, http.StatusConflict)
+	})
+
+	t.Run("it refuses bad league ids", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodPost, "/leagues/Friday_Night"))
+
+		tests.AssertStatus(t, response, http.StatusBadRequest)
+	})
+
+	t.Run("it lists the leagues", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodGet, "/leagues"))
+
+		var ids []string
+		if err := json.NewDecoder(response.Body).Decode(&ids); err != nil {
+			t.Fatalf("couldn't decode the leagues: %v", err)
+		}
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		if !slices.Equal(ids, []string{"friday", "tuesday"}) {
+			t.Errorf("got leagues %v, want [friday tuesday]", ids)
+		}
+	})
+
+	t.Run("it returns the league of a named league", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodGet, "/leagues/tuesday/league"))
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		tests.AssertLeague(t, getLeagueFromResponse(t, response.Body), engine.League{{Name: "Pepper", Wins: 2}})
+	})
+
+	t.Run("it returns scores from a named league", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodGet, "/leagues/tuesday/players/Pepper"))
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		tests.AssertResponseBody(t, response.Body.String(), "2")
+	})
+
+	t.Run("it records wins in the named league only", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodPost, "/leagues/friday/players/Floyd"))
+
+		tests.AssertStatus(t, response, http.StatusAccepted)
+		tests.AssertPlayerWin(t, leagues.Stores["friday"], "Floyd")
+		if len(store.WinCalls) != 0 {
+			t.Errorf("recorded %v in the default league, want nothing", store.WinCalls)
+		}
+	})
+
+	t.Run("it returns 404 for an unknown league", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodGet, "/leagues/sunday/league"))
+
+		tests.AssertStatus(t, response, http.StatusNotFound)
+	})
+
+	t.Run("it returns 404 for leagues when they aren't served", func(t *testing.T) {
+		server := mustMakePlayerServer(t, &store, tests.DummyGame)
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newRequest(http.MethodGet, "/leagues"))
+
+		tests.AssertStatus(t, response, http.StatusNotFound)
+	})
+}
+
+func TestGames(t *testing.T) {
+	game := &tests.GameSpy{Schedule: &tests.SpyBlindSchedule{}}
+	playerServer := mustMakePlayerServer(t, tests.DummyPlayerStore, game)
+
+	var created session.Session
+
+	t.Run("it creates a waiting game on POST", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodPost, "/games"))
+
+		tests.AssertStatus(t, response, http.StatusCreated)
+		created = getGameFromResponse(t, response.Body)
+		if created.ID == "" || created.State != session.Waiting {
+			t.Errorf("got game %+v, want a waiting game with an id", created)
+		}
+	})
+
+	t.Run("it plays the game joined on /ws", func(t *testing.T) {
+		testServer := httptest.NewServer(playerServer)
+		defer testServer.Close()
+
+		ws := mustDialWS(t, "ws"+strings.TrimPrefix(testServer.URL, "http")+"/ws?game="+created.ID)
+		defer func() {
+			if err := ws.Close(); err != nil {
+				t.Errorf("couldn't close the websocket: %v", err)
+			}
+		}()
+		writeMessage(t, ws, server.MsgStart, server.StartMessage{Players: 4})
+
+		running := retryUntil(500*time.Millisecond, func() bool {
+			return getGame(t, playerServer, created.ID).State == session.Running
+		})
+		if !running {
+			t.Fatal("the game never started")
+		}
+
+		response := httptest.NewRecorder()
+		playerServer.ServeHTTP(response, newRequest(http.MethodPost, "/games/"+created.ID+"/pause"))
+		tests.AssertStatus(t, response, http.StatusOK)
+		if got := getGameFromResponse(t, response.Body); got.State != session.Paused || got.Players != 4 {
+			t.Errorf("got game %+v, want a paused game of 4", got)
+		}
+
+		response = httptest.NewRecorder()
+		playerServer.ServeHTTP(response, newRequest(http.MethodPost, "/games/"+created.ID+"/pause"))
+		tests.AssertStatus(t, response, http.StatusConflict)
+
+		response = httptest.NewRecorder()
+		playerServer.ServeHTTP(response, newRequest(http.MethodPost, "/games/"+created.ID+"/resume"))
+		tests.AssertStatus(t, response, http.StatusOK)
+
+		writeMessage(t, ws, server.MsgFinish, server.FinishMessage{Winner: "Ruth"})
+		finished := retryUntil(500*time.Millisecond, func() bool {
+			return getGame(t, playerServer, created.ID).State == session.Finished
+		})
+		if !finished {
+			t.Fatal("the game never finished")
+		}
+		assertFinishCalledWith(t, game, "Ruth")
+	})
+
+	t.Run("it refuses to join a game that isn't waiting", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/ws?game="+created.ID))
+
+		tests.AssertStatus(t, response, http.StatusConflict)
+	})
+
+	t.Run("it lists the games", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/games"))
+
+		var games []session.Session
+		if err := json.NewDecoder(response.Body).Decode(&games); err != nil {
+			t.Fatalf("couldn't decode the games: %v", err)
+		}
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		if len(games) != 1 || games[0].ID != created.ID || games[0].Winner != "Ruth" {
+			t.Errorf("got games %+v, want the game won by Ruth", games)
+		}
+	})
+
+	t.Run("it returns 404 for unknown games", func(t *testing.T) {
+		for _, url := range []string{"/games/missing", "/ws?game=missing"} {
+			response := httptest.NewRecorder()
+
+			playerServer.ServeHTTP(response, newRequest(http.MethodGet, url))
+
+			tests.AssertStatus(t, response, http.StatusNotFound)
+		}
+	})
+}
+
+func TestWatchGame(t *testing.T) {
+	game := &tests.GameSpy{Level: &engine.BlindLevel{SmallBlind: 100, BigBlind: 200}}
+	playerServer := mustMakePlayerServer(t, tests.DummyPlayerStore, game)
+	testServer := httptest.NewServer(playerServer)
+	defer testServer.Close()
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http") + "/ws"
+
+	response := httptest.NewRecorder()
+	playerServer.ServeHTTP(response, newRequest(http.MethodPost, "/games"))
+	id := getGameFromResponse(t, response.Body).ID
+
+	t.Run("it passes the game on to every watcher", func(t *testing.T) {
+		var watchers []*websocket.Conn
+		for range 2 {
+			watcher := mustDialWS(t, url+"?game="+id+"&watch=true")
+			defer closeWS(t, watcher)
+
+			state := getState(t, waitForMessage(t, watcher, server.MsgState))
+			if state.State != session.Waiting || state.Token != "" {
+				t.Errorf("got %+v, want a waiting game without a token", state)
+			}
+			watchers = append(watchers, watcher)
+		}
+
+		player := mustDialWS(t, url+"?game="+id)
+		defer closeWS(t, player)
+		writeMessage(t, player, server.MsgStart, server.StartMessage{Players: 3})
+		waitForMessage(t, player, server.MsgBlind)
+		writeMessage(t, player, server.MsgFinish, server.FinishMessage{Winner: "Ruth"})
+
+		for _, watcher := range watchers {
+			assertWebsocketGotMsg(t, watcher, "Blind is now 100/200")
+			assertGameState(t, waitForMessage(t, watcher, server.MsgState), session.Running)
+			assertGameState(t, waitForMessage(t, watcher, server.MsgState), session.Finished)
+		}
+	})
+
+	t.Run("it doesn't let watchers play", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		playerServer.ServeHTTP(response, newRequest(http.MethodPost, "/games"))
+		id := getGameFromResponse(t, response.Body).ID
+
+		watcher := mustDialWS(t, url+"?game="+id+"&watch=true")
+		defer closeWS(t, watcher)
+
+		writeMessage(t, watcher, server.MsgStart, server.StartMessage{Players: 3})
+		waitForMessage(t, watcher, server.MsgError)
+		writeMessage(t, watcher, server.MsgPing, nil)
+		waitForMessage(t, watcher, server.MsgPing)
+
+		if got := getGame(t, playerServer, id); got.State != session.Waiting {
+			t.Errorf("got game %s, want it still waiting", got.State)
+		}
+	})
+
+	t.Run("it refuses to watch games that are over or unknown", func(t *testing.T) {
+		for query, want := range map[string]int{
+			"?game=" + id + "&watch=true": http.StatusConflict,
+			"?game=missing&watch=true":    http.StatusNotFound,
+		} {
+			response := httptest.NewRecorder()
+			playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/ws"+query))
+
+			tests.AssertStatus(t, response, want)
+		}
+	})
+}
+
 func TestStoreWins(t *testing.T) {
 	store := tests.StubPlayerStore{
 		Scores: map[string]int{},
@@ -103,6 +597,27 @@ func TestStoreWins(t *testing.T) {
 	})
 }
 
+func TestStoreFailures(t *testing.T) {
+	store := tests.StubPlayerStore{Err: errors.New("disk full")}
+	server := mustMakePlayerServer(t, &store, tests.DummyGame)
+
+	cases := map[string]*http.Request{
+		"get score":  newGetScoreRequest("Pepper"),
+		"post win":   newPostWinRequest("Pepper"),
+		"get league": newLeagueRequest(),
+	}
+
+	for name, request := range cases {
+		t.Run(name+" returns 500", func(t *testing.T) {
+			response := httptest.NewRecorder()
+
+			server.ServeHTTP(response, request)
+
+			tests.AssertStatus(t, response, http.StatusInternalServerError)
+		})
+	}
+}
+
 func TestGame(t *testing.T) {
 	t.Run("Get /game returns 200", func(t *testing.T) {
 		game := &tests.GameSpy{}
@@ -121,27 +636,248 @@ func TestGame(t *testing.T) {
 		winner := "Ruth"
 
 		game := &tests.GameSpy{BlindAlert: []byte(wantedBlindAlert)}
-		server := httptest.NewServer(mustMakePlayerServer(t, tests.DummyPlayerStore, game))
-		defer server.Close()
+		testServer := httptest.NewServer(mustMakePlayerServer(t, tests.DummyPlayerStore, game))
+		defer testServer.Close()
 
-		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
-		ws := mustDialWS(t, wsURL)
-		closeWrapper := func() {
-			if err := ws.Close(); err != nil {
-				t.Errorf("couldn't close the websocket: %v", err)
-			}
-		}
-		defer closeWrapper()
+		ws := mustDialWS(t, "ws"+strings.TrimPrefix(testServer.URL, "http")+"/ws")
+		defer closeWS(t, ws)
+
+		assertGameState(t, waitForMessage(t, ws, server.MsgState), session.Waiting)
 
-		writeMessage(t, ws, "3")
-		writeMessage(t, ws, winner)
+		writeMessage(t, ws, server.MsgStart, server.StartMessage{Players: 3})
+		started := waitForMessages(t, ws, server.MsgBlind, server.MsgState)
+		assertBlindMessage(t, started[server.MsgBlind], wantedBlindAlert)
+		assertGameState(t, started[server.MsgState], session.Running)
+
+		writeMessage(t, ws, server.MsgFinish, server.FinishMessage{Winner: winner})
+		assertGameState(t, waitForMessage(t, ws, server.MsgState), session.Finished)
 
-		time.Sleep(time.Millisecond * 10)
 		assertGameStartedWith(t, game, 3)
 		assertFinishCalledWith(t, game, winner)
-		assertWebsocketGotMsg(t, ws, wantedBlindAlert)
+	})
+
+	t.Run("only the player gets the token of the game", func(t *testing.T) {
+		testServer := httptest.NewServer(mustMakePlayerServer(t, tests.DummyPlayerStore, &tests.GameSpy{}))
+		defer testServer.Close()
+		url := "ws" + strings.TrimPrefix(testServer.URL, "http") + "/ws"
 
+		player := mustDialWS(t, url)
+		defer closeWS(t, player)
+		hosted := getState(t, waitForMessage(t, player, server.MsgState))
+		if hosted.Token == "" {
+			t.Fatal("the player didn't get a token to reconnect with")
+		}
+
+		other := mustDialWS(t, url+"?game="+hosted.ID)
+		defer closeWS(t, other)
+		if joined := getState(t, waitForMessage(t, other, server.MsgState)); joined.Token != "" {
+			t.Errorf("got token %q for a second connection, want none", joined.Token)
+		}
+
+		writeMessage(t, other, server.MsgStart, server.StartMessage{Players: 3})
+		waitForMessage(t, other, server.MsgError)
+		writeMessage(t, player, server.MsgStart, server.StartMessage{Players: 3})
+		if started := getState(t, waitForMessage(t, player, server.MsgState)); started.State != session.Running || started.Token != hosted.Token {
+			t.Errorf("got %+v, want the game running with the player's token", started)
+		}
 	})
+
+	t.Run("it asks to confirm winners who weren't named as players", func(t *testing.T) {
+		game := &tests.GameSpy{}
+		testServer := httptest.NewServer(mustMakePlayerServer(t, tests.DummyPlayerStore, game))
+		defer testServer.Close()
+
+		ws := mustDialWS(t, "ws"+strings.TrimPrefix(testServer.URL, "http")+"/ws")
+		defer closeWS(t, ws)
+
+		assertGameState(t, waitForMessage(t, ws, server.MsgState), session.Waiting)
+
+		writeMessage(t, ws, server.MsgStart, server.StartMessage{Participants: []string{"Ruth", " Cleo "}})
+		assertGameState(t, waitForMessage(t, ws, server.MsgState), session.Running)
+		assertGameStartedWith(t, game, 2)
+
+		writeMessage(t, ws, server.MsgFinish, server.FinishMessage{Winner: "Floyd"})
+		var problem server.ErrorMessage
+		tests.AssertNoError(t, json.Unmarshal(waitForMessage(t, ws, server.MsgError).Data, &problem))
+		if !problem.Confirm {
+			t.Errorf("got error %+v, want it to ask for confirmation", problem)
+		}
+
+		writeMessage(t, ws, server.MsgFinish, server.FinishMessage{Winner: "Floyd", Confirm: true})
+		assertGameState(t, waitForMessage(t, ws, server.MsgState), session.Finished)
+
+		if !slices.Equal(game.StartedWithNames(), []string{"Ruth", "Cleo"}) || !game.Confirmed() {
+			t.Errorf("got players %q and confirmed %v, want Ruth and Cleo and Floyd confirmed", game.StartedWithNames(), game.Confirmed())
+		}
+	})
+
+	presets := engine.DefaultBlindPresets()
+	presets["turbo"] = engine.BlindStructure{
+		Name:          "turbo",
+		LevelDuration: engine.Duration(3 * time.Minute),
+		Levels:        []engine.BlindLevel{{SmallBlind: 25, BigBlind: 50}},
+	}
+
+	t.Run("Get /game offers the blind presets", func(t *testing.T) {
+		server := mustMakePlayerServer(t, &tests.StubPlayerStore{}, &tests.GameSpy{}, server.WithBlindPresets(presets))
+		response := httptest.NewRecorder()
+
+		server.ServeHTTP(response, newGameRequest())
+
+		tests.AssertStatus(t, response, http.StatusOK)
+		for _, name := range []string{engine.StandardBlindsName, "turbo"} {
+			if !strings.Contains(response.Body.String(), `<option value="`+name+`"`) {
+				t.Errorf("the game page doesn't offer the %s blinds", name)
+			}
+		}
+	})
+
+	t.Run("it starts the game with the blinds picked", func(t *testing.T) {
+		game := &tests.GameSpy{}
+		testServer := httptest.NewServer(mustMakePlayerServer(t, tests.DummyPlayerStore, game, server.WithBlindPresets(presets)))
+		defer testServer.Close()
+
+		ws := mustDialWS(t, "ws"+strings.TrimPrefix(testServer.URL, "http")+"/ws")
+		defer closeWS(t, ws)
+
+		writeMessage(t, ws, server.MsgStart, server.StartMessage{Players: 3, Blinds: "turbo"})
+		writeMessage(t, ws, server.MsgFinish, server.FinishMessage{Winner: "Ruth"})
+
+		assertFinishCalledWith(t, game, "Ruth")
+		if game.StartedWithBlinds().Name != "turbo" {
+			t.Errorf("got the game started with the %q blinds, want turbo", game.StartedWithBlinds().Name)
+		}
+	})
+
+	t.Run("it stops the game when nobody reconnects before the winner is known", func(t *testing.T) {
+		game := &tests.GameSpy{}
+		clock := tests.NewManualClock(time.Date(2026, time.March, 14, 19, 0, 0, 0, time.UTC))
+		testServer := httptest.NewServer(mustMakePlayerServer(t, tests.DummyPlayerStore, game, server.WithClock(clock)))
+		defer testServer.Close()
+
+		ws := mustDialWS(t, "ws"+strings.TrimPrefix(testServer.URL, "http")+"/ws")
+		writeMessage(t, ws, server.MsgStart, server.StartMessage{Players: 3})
+		waitForMessage(t, ws, server.MsgState)
+		if err := ws.Close(); err != nil {
+			t.Fatalf("couldn't close the websocket: %v", err)
+		}
+
+		stopped := retryUntil(500*time.Millisecond, func() bool {
+			// the grace period starts once the server notices the close
+			clock.Advance(session.ReconnectGrace)
+			return game.StopCalled()
+		})
+		if !stopped {
+			t.Error("expected the game to be stopped")
+		}
+		if game.FinishCalled() {
+			t.Error("the game was finished without a winner")
+		}
+	})
+
+	t.Run("it lets the player reconnect with the token and finish the game", func(t *testing.T) {
+		game := &tests.GameSpy{Level: &engine.BlindLevel{SmallBlind: 100, BigBlind: 200}}
+		testServer := httptest.NewServer(mustMakePlayerServer(t, tests.DummyPlayerStore, game))
+		defer testServer.Close()
+		url := "ws" + strings.TrimPrefix(testServer.URL, "http") + "/ws"
+
+		ws := mustDialWS(t, url)
+		writeMessage(t, ws, server.MsgStart, server.StartMessage{Players: 3})
+		state := getState(t, waitForMessages(t, ws, server.MsgBlind, server.MsgState)[server.MsgState])
+		if state.Token == "" {
+			t.Fatal("didn't get a token to reconnect with")
+		}
+		closeWS(t, ws)
+
+		ws = mustDialWS(t, url+"?game="+state.ID+"&token="+state.Token)
+		defer closeWS(t, ws)
+
+		assertGameState(t, waitForMessage(t, ws, server.MsgState), session.Running)
+		assertWebsocketGotMsg(t, ws, "Blind is now 100/200")
+
+		writeMessage(t, ws, server.MsgFinish, server.FinishMessage{Winner: "Ruth"})
+		assertGameState(t, waitForMessage(t, ws, server.MsgState), session.Finished)
+		assertFinishCalledWith(t, game, "Ruth")
+		if game.StopCalled() {
+			t.Error("the game was stopped while the player reconnected")
+		}
+	})
+
+	t.Run("it refuses to reconnect without the right token", func(t *testing.T) {
+		playerServer := mustMakePlayerServer(t, tests.DummyPlayerStore, &tests.GameSpy{})
+		testServer := httptest.NewServer(playerServer)
+		defer testServer.Close()
+
+		ws := mustDialWS(t, "ws"+strings.TrimPrefix(testServer.URL, "http")+"/ws")
+		defer closeWS(t, ws)
+		writeMessage(t, ws, server.MsgStart, server.StartMessage{Players: 3})
+		id := getState(t, waitForMessage(t, ws, server.MsgState)).ID
+
+		response := httptest.NewRecorder()
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/ws?game="+id+"&token=guessed"))
+
+		tests.AssertStatus(t, response, http.StatusForbidden)
+	})
+
+	t.Run("it answers messages it can't handle with an error", func(t *testing.T) {
+		game := &tests.GameSpy{}
+		testServer := httptest.NewServer(mustMakePlayerServer(t, tests.DummyPlayerStore, game, server.WithBlindPresets(presets)))
+		defer testServer.Close()
+
+		ws := mustDialWS(t, "ws"+strings.TrimPrefix(testServer.URL, "http")+"/ws")
+		defer closeWS(t, ws)
+
+		cases := map[string]string{
+			"not json":            `3`,
+			"old version":         `{"v": 0, "type": "start", "data": {"players": 3}}`,
+			"no type":             `{"v": 1}`,
+			"server messages":     `{"v": 1, "type": "blind", "data": {"text": "Blind is 100"}}`,
+			"no data":             `{"v": 1, "type": "start"}`,
+			"unknown fields":      `{"v": 1, "type": "start", "data": {"players": 3, "seats": 9}}`,
+			"too few players":     `{"v": 1, "type": "start", "data": {"players": 1}}`,
+			"too many players":    `{"v": 1, "type": "start", "data": {"players": 24}}`,
+			"miscounted players":  `{"v": 1, "type": "start", "data": {"players": 3, "participants": ["Ruth", "Cleo"]}}`,
+			"player named twice":  `{"v": 1, "type": "start", "data": {"participants": ["Ruth", "ruth"]}}`,
+			"unknown blinds":      `{"v": 1, "type": "start", "data": {"players": 3, "blinds": "glacial"}}`,
+			"finish too early":    `{"v": 1, "type": "finish", "data": {"winner": "Ruth"}}`,
+			"winner without name": `{"v": 1, "type": "finish", "data": {"winner": " "}}`,
+		}
+
+		for name, raw := range cases {
+			t.Run(name, func(t *testing.T) {
+				writeRaw(t, ws, raw)
+
+				msg := waitForMessage(t, ws, server.MsgError)
+				var got server.ErrorMessage
+				if err := json.Unmarshal(msg.Data, &got); err != nil || got.Message == "" {
+					t.Errorf("got error %s, want it explained", msg.Data)
+				}
+			})
+		}
+
+		writeMessage(t, ws, server.MsgPing, nil)
+		waitForMessage(t, ws, server.MsgPing)
+
+		if game.StartCalled() {
+			t.Error("the game was started by a bad message")
+		}
+	})
+}
+
+func assertProblem(t testing.TB, response *httptest.ResponseRecorder, want int) {
+	t.Helper()
+
+	tests.AssertStatus(t, response, want)
+	tests.AssertContentType(t, response, server.ProblemContentType)
+
+	var problem server.Problem
+	if err := json.NewDecoder(response.Body).Decode(&problem); err != nil {
+		t.Fatalf("couldn't decode the problem %q: %v", response.Body, err)
+	}
+
+	if problem.Status != want || problem.Title != http.StatusText(want) || problem.Type != "about:blank" {
+		t.Errorf("got problem %+v, want one for %d", problem, want)
+	}
 }
 
 func retryUntil(d time.Duration, f func() bool) bool {
@@ -160,35 +896,136 @@ func assertFinishCalledWith(t testing.TB, game *tests.GameSpy, winner string) {
 	t.Helper()
 
 	passed := retryUntil(500*time.Millisecond, func() bool {
-		return game.FinishedWith == winner
+		return game.FinishedWith() == winner
 	})
 
 	if !passed {
-		t.Errorf("expected Finish called with %q, but got %q", winner, game.FinishedWith)
+		t.Errorf("expected Finish called with %q, but got %q", winner, game.FinishedWith())
 	}
 
 }
 
 func assertWebsocketGotMsg(t *testing.T, ws *websocket.Conn, want string) {
-	_, gotBlindAlert, _ := ws.ReadMessage()
+	t.Helper()
+
+	assertBlindMessage(t, waitForMessage(t, ws, server.MsgBlind), want)
+}
+
+func assertBlindMessage(t testing.TB, msg server.Message, want string) {
+	t.Helper()
+
+	var got server.BlindMessage
+	if err := json.Unmarshal(msg.Data, &got); err != nil {
+		t.Fatalf("couldn't decode the blind message %s: %v", msg.Data, err)
+	}
+
+	if got.Text != want {
+		t.Errorf("got blind alert %q, wanted %q", got.Text, want)
+	}
+}
+
+func assertGameState(t testing.TB, msg server.Message, want session.State) {
+	t.Helper()
+
+	var game session.Session
+	if err := json.Unmarshal(msg.Data, &game); err != nil {
+		t.Fatalf("couldn't decode the state message %s: %v", msg.Data, err)
+	}
+
+	if game.State != want {
+		t.Errorf("got game %s, want %s", game.State, want)
+	}
+}
+
+func getState(t testing.TB, msg server.Message) (state server.StateMessage) {
+	t.Helper()
+
+	if err := json.Unmarshal(msg.Data, &state); err != nil {
+		t.Fatalf("couldn't decode the state message %s: %v", msg.Data, err)
+	}
+
+	return state
+}
+
+// waitForMessage reads messages until one of msgType comes.
+func waitForMessage(t testing.TB, ws *websocket.Conn, msgType server.MessageType) server.Message {
+	t.Helper()
+
+	if err := ws.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("couldn't set the read deadline: %v", err)
+	}
+
+	for {
+		var msg server.Message
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatalf("didn't get a %s message: %v", msgType, err)
+		}
+
+		if msg.Version != server.ProtocolVersion {
+			t.Errorf("got a message of version %d, want %d", msg.Version, server.ProtocolVersion)
+		}
+
+		if msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+// waitForMessages waits for a message of every type, in whatever order they
+// come, as blinds reach the player apart from the replies to their messages.
+func waitForMessages(t testing.TB, ws *websocket.Conn, msgTypes ...server.MessageType) map[server.MessageType]server.Message {
+	t.Helper()
 
-	if string(gotBlindAlert) != want {
-		t.Errorf("got blind alert %q, wanted %q", string(gotBlindAlert), want)
+	if err := ws.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("couldn't set the read deadline: %v", err)
 	}
 
+	got := map[server.MessageType]server.Message{}
+	for len(got) < len(msgTypes) {
+		var msg server.Message
+		if err := ws.ReadJSON(&msg); err != nil {
+			t.Fatalf("didn't get %v messages, only %d: %v", msgTypes, len(got), err)
+		}
+
+		if slices.Contains(msgTypes, msg.Type) {
+			got[msg.Type] = msg
+		}
+	}
+
+	return got
+}
+
+func writeMessage(t *testing.T, conn *websocket.Conn, msgType server.MessageType, data any) {
+	t.Helper()
+
+	msg, err := server.NewMessage(msgType, data)
+	if err != nil {
+		t.Fatalf("couldn't make the %s message: %v", msgType, err)
+	}
+
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("could not send message over ws connection %v", err)
+	}
 }
 
-func writeMessage(t *testing.T, conn *websocket.Conn, message string) {
+func writeRaw(t *testing.T, conn *websocket.Conn, message string) {
 	t.Helper()
 
 	if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
 		t.Fatalf("could not send message over ws connection %v", err)
 	}
+}
+
+func closeWS(t testing.TB, ws *websocket.Conn) {
+	t.Helper()
 
+	if err := ws.Close(); err != nil {
+		t.Errorf("couldn't close the websocket: %v", err)
+	}
 }
 
-func mustMakePlayerServer(t *testing.T, store engine.PlayerStore, game engine.Game) *server.PlayerServer {
-	server, err := server.NewPlayerServer(store, game)
+func mustMakePlayerServer(t *testing.T, store engine.PlayerStore, game engine.Game, opts ...server.Option) *server.PlayerServer {
+	server, err := server.NewPlayerServer(store, tests.NewGame(game), opts...)
 
 	if err != nil {
 		t.Fatal("problem creating player server", err)
@@ -208,6 +1045,20 @@ func mustDialWS(t *testing.T, url string) *websocket.Conn {
 	return ws
 }
 
+func assertDialRefused(t *testing.T, url string, want int) {
+	t.Helper()
+
+	ws, response, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		closeWS(t, ws)
+		t.Fatalf("opened a ws connection on %s, want it refused", url)
+	}
+
+	if response == nil || response.StatusCode != want {
+		t.Errorf("got response %v opening %s, want %d", response, url, want)
+	}
+}
+
 func newGetScoreRequest(name string) *http.Request {
 	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/players/%s", name), nil)
 
@@ -248,6 +1099,46 @@ func newLeagueRequest() *http.Request {
 	return request
 }
 
+func newRequest(method, url string) *http.Request {
+	request, err := http.NewRequest(method, url, nil)
+
+	if err != nil {
+		fmt.Printf("did not expect error in %s %s %v", method, url, err)
+	}
+
+	return request
+}
+
+func newLeagueRequestSortedBy(sortBy string) *http.Request {
+	request, err := http.NewRequest(http.MethodGet, "/league?sort="+sortBy, nil)
+
+	if err != nil {
+		fmt.Printf("did not expect error in get league %v", err)
+	}
+
+	return request
+}
+
+func getGame(t testing.TB, server http.Handler, id string) session.Session {
+	t.Helper()
+
+	response := httptest.NewRecorder()
+	server.ServeHTTP(response, newRequest(http.MethodGet, "/games/"+id))
+	tests.AssertStatus(t, response, http.StatusOK)
+
+	return getGameFromResponse(t, response.Body)
+}
+
+func getGameFromResponse(t testing.TB, body io.Reader) (game session.Session) {
+	t.Helper()
+
+	if err := json.NewDecoder(body).Decode(&game); err != nil {
+		t.Fatalf("unable to parse response from server %q into a game, %v", body, err)
+	}
+
+	return game
+}
+
 func getLeagueFromResponse(t testing.TB, body io.Reader) (league engine.League) {
 	t.Helper()
 
@@ -263,8 +1154,8 @@ func getLeagueFromResponse(t testing.TB, body io.Reader) (league engine.League)
 func assertGameStartedWith(t testing.TB, game *tests.GameSpy, numberOfPlayers int) {
 	t.Helper()
 
-	if game.StartedWith != numberOfPlayers {
-		t.Errorf("wanted Start called with %d, but go %d", numberOfPlayers, game.StartedWith)
+	if game.StartedWith() != numberOfPlayers {
+		t.Errorf("wanted Start called with %d, but go %d", numberOfPlayers, game.StartedWith())
 	}
 
 }
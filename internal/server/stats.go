@@ -0,0 +1,57 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// statsHandler sums up how the player of the path has done in the running
+// season, like their score.
+func (p *PlayerServer) statsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "player stats can only be checked with GET", http.MethodGet) {
+		return
+	}
+
+	name, ok := playerName(w, r)
+	if !ok {
+		return
+	}
+
+	name, err := p.lookUp(r.Context(), name)
+	if err != nil {
+		writeRegistryError(w, err)
+		return
+	}
+
+	wins, err := p.score(r.Context(), name)
+	if err != nil {
+		writeScoreError(w, err)
+		return
+	}
+
+	store := p.storeFor(r.Context())
+
+	matches, err := store.GetMatches(r.Context())
+	if err != nil {
+		log.Println("couldn't get the matches: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't get the stats")
+		return
+	}
+
+	seasons, err := store.GetSeasons(r.Context())
+	if err != nil {
+		log.Println("couldn't get the seasons: ", err)
+		writeProblem(w, http.StatusInternalServerError, "couldn't get the stats")
+		return
+	}
+
+	var since time.Time
+	if len(seasons) > 0 {
+		since = seasons[len(seasons)-1].EndedAt
+	}
+
+	writeJSON(w, http.StatusOK, engine.PlayerStats(name, wins, matches, since, p.rating))
+}
@@ -0,0 +1,108 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/server"
+	"github.com/oblassov/game-score-server/internal/storage/filesystem"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestPlayerStats(t *testing.T) {
+	at := time.Date(2026, time.June, 5, 20, 0, 0, 0, time.UTC)
+	store := tests.StubPlayerStore{
+		Scores: map[string]int{"Cleo": 2, "Chris": 0},
+		Matches: []engine.Match{
+			{ID: "m1", FinishedAt: at, Participants: []string{"Cleo", "Chris", "Ruth"}, FinishingOrder: []string{"Cleo"}, Winner: "Cleo"},
+			{ID: "m2", FinishedAt: at.Add(time.Hour), Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Cleo"}, Winner: "Cleo"},
+		},
+	}
+	playerServer := mustMakePlayerServer(t, &store, tests.DummyGame)
+
+	for _, path := range []string{"/players/Cleo/stats", server.APIPrefix + "/players/Cleo/stats"} {
+		t.Run("it sums up how the player did on "+path, func(t *testing.T) {
+			response := httptest.NewRecorder()
+
+			playerServer.ServeHTTP(response, newRequest(http.MethodGet, path))
+
+			tests.AssertStatus(t, response, http.StatusOK)
+			tests.AssertContentType(t, response, server.JSONContentType)
+
+			var stats engine.Stats
+			if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+				t.Fatalf("couldn't decode the stats: %v", err)
+			}
+			if stats.Wins != 2 || stats.GamesPlayed != 2 || stats.WinPercentage != 100 || stats.CurrentStreak != 2 || stats.AverageFieldSize != 2.5 {
+				t.Errorf("got stats %+v, want 2 wins in 2 games of 2.5 players", stats)
+			}
+			if !stats.LastPlayed.Equal(at.Add(time.Hour)) || len(stats.RatingHistory) != 2 {
+				t.Errorf("got stats %+v, want the last game and a rating after each", stats)
+			}
+		})
+	}
+
+	t.Run("it counts the games of players who never won", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/players/Chris/stats"))
+
+		tests.AssertStatus(t, response, http.StatusOK)
+	})
+
+	t.Run("it returns 404 for players who never played", func(t *testing.T) {
+		response := httptest.NewRecorder()
+
+		playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/players/Floyd/stats"))
+
+		assertProblem(t, response, http.StatusNotFound)
+	})
+}
+
+func TestPlayerStatsOfTheRunningSeason(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2026, time.June, 5, 20, 0, 0, 0, time.UTC)
+	database, cleanDatabase := tests.CreateTempFile(t, `[]`)
+	defer cleanDatabase()
+
+	store, err := filesystem.NewPlayerStore(database)
+	tests.AssertNoError(t, err)
+
+	for _, match := range []engine.Match{
+		{ID: "m1", FinishedAt: at, Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Cleo"}, Winner: "Cleo"},
+		{ID: "m2", FinishedAt: at.Add(time.Hour), Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Cleo"}, Winner: "Cleo"},
+	} {
+		tests.AssertNoError(t, store.RecordMatch(ctx, match))
+	}
+	_, err = store.CloseSeason(ctx, "2026-Q2", at.Add(2*time.Hour))
+	tests.AssertNoError(t, err)
+	for _, match := range []engine.Match{
+		{ID: "m3", FinishedAt: at.Add(3 * time.Hour), Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Chris"}, Winner: "Chris"},
+		{ID: "m4", FinishedAt: at.Add(4 * time.Hour), Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Cleo"}, Winner: "Cleo"},
+	} {
+		tests.AssertNoError(t, store.RecordMatch(ctx, match))
+	}
+
+	playerServer := mustMakePlayerServer(t, store, tests.DummyGame)
+	response := httptest.NewRecorder()
+
+	playerServer.ServeHTTP(response, newRequest(http.MethodGet, "/players/Cleo/stats"))
+
+	tests.AssertStatus(t, response, http.StatusOK)
+
+	var stats engine.Stats
+	if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+		t.Fatalf("couldn't decode the stats: %v", err)
+	}
+	if stats.Wins != 1 || stats.GamesPlayed != 2 || stats.WinPercentage != 50 || stats.LongestStreak != 1 {
+		t.Errorf("got stats %+v, want 1 win in the 2 games since the season closed", stats)
+	}
+	if len(stats.RatingHistory) != 2 || stats.RatingHistory[0].Match != "m3" || stats.RatingHistory[0].Rating <= engine.DefaultRating {
+		t.Errorf("got rating history %+v, want the 2 games since the season closed, rated after the rest", stats.RatingHistory)
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// streamHeartbeat is how often an idle stream is written to, so proxies
+// don't take it for dead.
+const streamHeartbeat = 30 * time.Second
+
+// leagueStream sends the league as a server-sent event, then again every time
+// it changes until the client goes away.
+func (p *PlayerServer) leagueStream(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "the league can only be followed with GET", http.MethodGet) {
+		return
+	}
+
+	ctx := r.Context()
+	store := p.storeFor(ctx)
+
+	notifier, ok := store.(engine.ChangeNotifier)
+	if !ok {
+		writeProblem(w, http.StatusNotImplemented, "the league can't be followed live, poll it instead")
+		return
+	}
+
+	// subscribing first, so no change is missed between the first league
+	// sent and the next
+	changes, cancel := notifier.Subscribe()
+	defer cancel()
+
+	stream := http.NewResponseController(w)
+	// the stream lasts longer than the server lets responses be written for
+	if err := stream.SetWriteDeadline(time.Time{}); err != nil {
+		log.Println("couldn't lift the write deadline: ", err)
+	}
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		league, err := store.GetLeague(ctx)
+		if err != nil {
+			log.Println("couldn't get the league: ", err)
+			return
+		}
+
+		if err := writeEvent(w, "league", league); err != nil {
+			log.Println("couldn't stream the league: ", err)
+			return
+		}
+		if err := stream.Flush(); err != nil {
+			log.Println("couldn't flush the stream: ", err)
+			return
+		}
+
+		if !nextChange(ctx, w, stream, changes, heartbeat.C) {
+			return
+		}
+	}
+}
+
+// nextChange waits for the next change, keeping the stream alive meanwhile.
+// It reports false once the stream is over.
+func nextChange(ctx context.Context, w http.ResponseWriter, stream *http.ResponseController, changes <-chan struct{}, heartbeat <-chan time.Time) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-changes:
+			return true
+		case <-heartbeat:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return false
+			}
+			if err := stream.Flush(); err != nil {
+				return false
+			}
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("couldn't encode the %s event: %w", event, err)
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+	return err
+}
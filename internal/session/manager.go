@@ -0,0 +1,471 @@
+// Package session runs several games at once, each at a table of its own.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+type State string
+
+const (
+	// Waiting games were created but haven't started yet.
+	Waiting State = "waiting"
+	// Running games have blinds going up.
+	Running State = "running"
+	// Paused games have their blinds on hold.
+	Paused State = "paused"
+	// Finished games are over, with a winner unless they were stopped.
+	Finished State = "finished"
+)
+
+const (
+	// FinishedRetention is how long finished games can still be looked up.
+	FinishedRetention = 24 * time.Hour
+	// ReconnectGrace is how long a game goes on without its player before it
+	// is stopped.
+	ReconnectGrace = 5 * time.Minute
+)
+
+var (
+	ErrGameNotFound = errors.New("game not found")
+	ErrWrongState   = errors.New("game can't do that now")
+	ErrBadToken     = errors.New("wrong token for the game")
+	ErrNotPlayer    = errors.New("someone else is playing the game")
+)
+
+// Session describes a game at one point in time.
+type Session struct {
+	ID         string    `json:"id"`
+	State      State     `json:"state"`
+	Players    int       `json:"players,omitempty"`
+	Blinds     string    `json:"blinds,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	Winner     string    `json:"winner,omitempty"`
+	// Level is the blind level alerted last.
+	Level *engine.BlindLevel `json:"level,omitempty"`
+	// NextLevelIn is how long until the blinds go up in a running or paused
+	// game.
+	NextLevelIn engine.Duration `json:"next_level_in,omitempty"`
+}
+
+// Manager keeps track of the games being played.
+type Manager struct {
+	newGame func() engine.Game
+	clock   engine.Clock
+
+	lock  sync.RWMutex
+	games map[string]*table
+	// order has the ids of the games from the first created.
+	order []string
+}
+
+// table is a game with what's known about it. Its lock is held while the game
+// starts or finishes, so one slow game doesn't hold up the others.
+type table struct {
+	lock    sync.Mutex
+	game    engine.Game
+	session Session
+	// token lets the player who started the game back in after losing the
+	// connection.
+	token string
+	// participants are the players of the game, if they were named.
+	participants []string
+	out          *relay
+	// abandoned stops the game unless the player reconnects in time.
+	abandoned engine.Timer
+}
+
+// NewManager plays every game with one made by newGame.
+func NewManager(newGame func() engine.Game, clock engine.Clock) *Manager {
+	return &Manager{
+		newGame: newGame,
+		clock:   clock,
+		games:   map[string]*table{},
+	}
+}
+
+// Create sets up a game waiting for players.
+func (m *Manager) Create() Session {
+	return m.create(nil)
+}
+
+// Host sets up a game waiting for players, played from player, who is the
+// only one it's stopped for when they leave before it starts.
+func (m *Manager) Host(player io.Writer) Session {
+	return m.create(player)
+}
+
+func (m *Manager) create(player io.Writer) Session {
+	now := m.clock.Now().UTC()
+	t := &table{
+		game:    m.newGame(),
+		session: Session{ID: newID(), State: Waiting, CreatedAt: now},
+		token:   newToken(),
+		out:     &relay{},
+	}
+	if player != nil {
+		t.out.attach(player)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.prune(now)
+	m.games[t.session.ID] = t
+	m.order = append(m.order, t.session.ID)
+
+	return t.session
+}
+
+// Join makes player the one playing the waiting game id, unless someone else
+// already is, reporting whether they are.
+func (m *Manager) Join(id string, player io.Writer) (Session, bool, error) {
+	var joined bool
+
+	game, err := m.update(id, func(t *table) error {
+		if t.session.State != Waiting {
+			return t.wrongState("join")
+		}
+
+		joined = t.out.claim(player)
+		return nil
+	})
+
+	return game, joined, err
+}
+
+// Start starts the waiting game id, alerting blinds to alertsDestination,
+// which must be the player's if someone joined it. participants are its
+// players, if they were named.
+func (m *Manager) Start(id string, numberOfPlayers int, participants []string, blinds engine.BlindStructure, alertsDestination io.Writer) (Session, error) {
+	return m.update(id, func(t *table) error {
+		if t.session.State != Waiting {
+			return t.wrongState("start")
+		}
+
+		if !t.out.claim(alertsDestination) {
+			return fmt.Errorf("%w: %s", ErrNotPlayer, id)
+		}
+		t.game.Start(numberOfPlayers, participants, blinds, t.out)
+		t.participants = participants
+		t.session.State = Running
+		t.session.Players = numberOfPlayers
+		t.session.Blinds = blinds.Name
+		t.session.StartedAt = m.clock.Now().UTC()
+		return nil
+	})
+}
+
+// Pause puts the blinds of the running game id on hold.
+func (m *Manager) Pause(id string) (Session, error) {
+	return m.update(id, func(t *table) error {
+		if t.session.State != Running {
+			return t.wrongState("pause")
+		}
+
+		if blinds := t.game.Blinds(); blinds != nil {
+			blinds.Pause()
+		}
+		t.session.State = Paused
+		return nil
+	})
+}
+
+// Resume carries on with the blinds of the paused game id.
+func (m *Manager) Resume(id string) (Session, error) {
+	return m.update(id, func(t *table) error {
+		if t.session.State != Paused {
+			return t.wrongState("resume")
+		}
+
+		if blinds := t.game.Blinds(); blinds != nil {
+			blinds.Resume()
+		}
+		t.session.State = Running
+		return nil
+	})
+}
+
+// Finish records winner as the winner of game id, even if they didn't play
+// when confirmed. A game that couldn't be recorded keeps running, so
+// finishing it can be tried again.
+func (m *Manager) Finish(ctx context.Context, id, winner string, confirmed bool) (Session, error) {
+	return m.update(id, func(t *table) error {
+		if t.session.State != Running && t.session.State != Paused {
+			return t.wrongState("finish")
+		}
+
+		if err := t.game.Finish(ctx, winner, confirmed); err != nil {
+			return err
+		}
+		if seated, err := engine.SeatedWinner(t.participants, winner); err == nil {
+			winner = seated
+		}
+		t.session.State = Finished
+		t.session.Winner = winner
+		t.session.FinishedAt = m.clock.Now().UTC()
+		if t.abandoned != nil {
+			t.abandoned.Stop()
+			t.abandoned = nil
+		}
+		return nil
+	})
+}
+
+// Stop ends game id without a winner, like when everyone left the table.
+// Stopping a finished game does nothing.
+func (m *Manager) Stop(id string) (Session, error) {
+	return m.update(id, func(t *table) error {
+		m.stop(t)
+		return nil
+	})
+}
+
+// Token is the secret the player of game id reconnects with.
+func (m *Manager) Token(id string) (string, error) {
+	t, err := m.table(id)
+	if err != nil {
+		return "", err
+	}
+
+	return t.token, nil
+}
+
+// CheckToken makes sure token lets a player back into game id, which must
+// still be going on.
+func (m *Manager) CheckToken(id, token string) error {
+	_, err := m.update(id, func(t *table) error {
+		return t.checkToken(token)
+	})
+
+	return err
+}
+
+// Reconnect has the alerts of game id passed on to alertsDestination from now
+// on, if token is the game's.
+func (m *Manager) Reconnect(id, token string, alertsDestination io.Writer) (Session, error) {
+	return m.update(id, func(t *table) error {
+		if err := t.checkToken(token); err != nil {
+			return err
+		}
+
+		if t.abandoned != nil {
+			t.abandoned.Stop()
+			t.abandoned = nil
+		}
+		t.out.attach(alertsDestination)
+		return nil
+	})
+}
+
+// Disconnect stops passing on the alerts of game id to alertsDestination, as
+// the player lost the connection. A game that didn't start is stopped, while
+// one being played waits ReconnectGrace for the player to reconnect. Anyone
+// else leaving changes nothing.
+func (m *Manager) Disconnect(id string, alertsDestination io.Writer) (Session, error) {
+	return m.update(id, func(t *table) error {
+		if !t.out.detach(alertsDestination) {
+			// someone who wasn't playing, or the player already reconnected
+			// elsewhere
+			return nil
+		}
+
+		switch t.session.State {
+		case Waiting:
+			m.stop(t)
+		case Running, Paused:
+			if t.abandoned == nil {
+				t.abandoned = m.clock.AfterFunc(ReconnectGrace, func() {
+					m.abandon(id)
+				})
+			}
+		}
+
+		return nil
+	})
+}
+
+// Watch passes on the alerts of game id to alertsDestination along with the
+// player's, and how the game changes if it is a StateWriter, until it
+// unwatches.
+func (m *Manager) Watch(id string, alertsDestination io.Writer) (Session, error) {
+	return m.update(id, func(t *table) error {
+		if t.session.State == Finished {
+			return t.wrongState("watch")
+		}
+
+		t.out.watch(alertsDestination)
+		return nil
+	})
+}
+
+// Unwatch stops passing on game id to alertsDestination.
+func (m *Manager) Unwatch(id string, alertsDestination io.Writer) (Session, error) {
+	return m.update(id, func(t *table) error {
+		t.out.unwatch(alertsDestination)
+		return nil
+	})
+}
+
+// abandon stops game id if its player never came back.
+func (m *Manager) abandon(id string) {
+	_, err := m.update(id, func(t *table) error {
+		if t.abandoned != nil {
+			t.abandoned = nil
+			m.stop(t)
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Println("couldn't stop the abandoned game: ", err)
+	}
+}
+
+func (m *Manager) stop(t *table) {
+	if t.session.State == Finished {
+		return
+	}
+
+	t.game.Stop()
+	t.session.State = Finished
+	t.session.FinishedAt = m.clock.Now().UTC()
+}
+
+func (m *Manager) Get(id string) (Session, error) {
+	t, err := m.table(id)
+	if err != nil {
+		return Session{}, err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.describe(), nil
+}
+
+// List describes every game, the one created first first.
+func (m *Manager) List() []Session {
+	m.lock.RLock()
+	tables := make([]*table, 0, len(m.order))
+	for _, id := range m.order {
+		tables = append(tables, m.games[id])
+	}
+	m.lock.RUnlock()
+
+	sessions := make([]Session, 0, len(tables))
+	for _, t := range tables {
+		t.lock.Lock()
+		sessions = append(sessions, t.describe())
+		t.lock.Unlock()
+	}
+
+	return sessions
+}
+
+func (m *Manager) update(id string, change func(t *table) error) (Session, error) {
+	t, err := m.table(id)
+	if err != nil {
+		return Session{}, err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	before := t.session
+	if err := change(t); err != nil {
+		return t.describe(), err
+	}
+
+	game := t.describe()
+	if t.session != before {
+		t.out.changed(game)
+	}
+
+	return game, nil
+}
+
+func (m *Manager) table(id string) (*table, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	t, ok := m.games[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrGameNotFound, id)
+	}
+
+	return t, nil
+}
+
+// prune forgets games finished more than FinishedRetention before now.
+func (m *Manager) prune(now time.Time) {
+	m.order = slices.DeleteFunc(m.order, func(id string) bool {
+		t := m.games[id]
+
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		expired := t.session.State == Finished && now.Sub(t.session.FinishedAt) > FinishedRetention
+		if expired {
+			delete(m.games, id)
+		}
+		return expired
+	})
+}
+
+func (t *table) describe() Session {
+	session := t.session
+	session.Level = t.out.level()
+
+	if session.State == Running || session.State == Paused {
+		if blinds := t.game.Blinds(); blinds != nil {
+			session.NextLevelIn = engine.Duration(blinds.Remaining())
+		}
+	}
+
+	return session
+}
+
+func (t *table) checkToken(token string) error {
+	if subtle.ConstantTimeCompare([]byte(token), []byte(t.token)) != 1 {
+		return fmt.Errorf("%w: %s", ErrBadToken, t.session.ID)
+	}
+
+	if t.session.State != Running && t.session.State != Paused {
+		return t.wrongState("reconnect to")
+	}
+
+	return nil
+}
+
+func (t *table) wrongState(action string) error {
+	return fmt.Errorf("%w: can't %s a game that is %s", ErrWrongState, action, t.session.State)
+}
+
+func newID() string {
+	return randomHex(8)
+}
+
+func newToken() string {
+	return randomHex(32)
+}
+
+func randomHex(size int) string {
+	b := make([]byte, size)
+	// crypto/rand.Read never returns an error
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,420 @@
+package session_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/session"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it takes a game from waiting to finished", func(t *testing.T) {
+		game := &tests.GameSpy{Schedule: &tests.SpyBlindSchedule{}}
+		manager := session.NewManager(tests.NewGame(game), newClock())
+
+		created := manager.Create()
+		assertState(t, created, session.Waiting)
+
+		started, err := manager.Start(created.ID, 5, nil, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, err)
+		assertState(t, started, session.Running)
+		if started.Players != 5 || started.Blinds != engine.StandardBlindsName {
+			t.Errorf("got %+v, want a game of 5 with the standard blinds", started)
+		}
+
+		paused, err := manager.Pause(created.ID)
+		tests.AssertNoError(t, err)
+		assertState(t, paused, session.Paused)
+		if !game.Schedule.Paused {
+			t.Error("the blinds weren't paused")
+		}
+
+		resumed, err := manager.Resume(created.ID)
+		tests.AssertNoError(t, err)
+		assertState(t, resumed, session.Running)
+		if game.Schedule.Paused {
+			t.Error("the blinds weren't resumed")
+		}
+
+		finished, err := manager.Finish(ctx, created.ID, "Ruth", false)
+		tests.AssertNoError(t, err)
+		assertState(t, finished, session.Finished)
+		if finished.Winner != "Ruth" || game.FinishedWith() != "Ruth" {
+			t.Errorf("got %+v finished with %q, want Ruth to win", finished, game.FinishedWith())
+		}
+	})
+
+	t.Run("it refuses changes that don't fit the state of the game", func(t *testing.T) {
+		manager := session.NewManager(tests.NewGame(&tests.GameSpy{}), newClock())
+		id := manager.Create().ID
+
+		_, err := manager.Pause(id)
+		assertError(t, err, session.ErrWrongState)
+
+		_, err = manager.Finish(ctx, id, "Ruth", false)
+		assertError(t, err, session.ErrWrongState)
+
+		_, err = manager.Start(id, 5, nil, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, err)
+		_, err = manager.Start(id, 5, nil, engine.StandardBlinds(), io.Discard)
+		assertError(t, err, session.ErrWrongState)
+
+		_, err = manager.Get("missing")
+		assertError(t, err, session.ErrGameNotFound)
+	})
+
+	t.Run("it keeps a game running when it couldn't be recorded", func(t *testing.T) {
+		game := &tests.GameSpy{FinishErr: errors.New("disk full")}
+		manager := session.NewManager(tests.NewGame(game), newClock())
+		id := manager.Create().ID
+		_, err := manager.Start(id, 5, nil, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, err)
+
+		got, err := manager.Finish(ctx, id, "Ruth", false)
+		if err == nil {
+			t.Fatal("expected an error but didn't get one")
+		}
+		assertState(t, got, session.Running)
+	})
+
+	t.Run("it stops games without a winner", func(t *testing.T) {
+		game := &tests.GameSpy{}
+		manager := session.NewManager(tests.NewGame(game), newClock())
+		id := manager.Create().ID
+
+		stopped, err := manager.Stop(id)
+		tests.AssertNoError(t, err)
+		assertState(t, stopped, session.Finished)
+		if !game.StopCalled() || stopped.Winner != "" {
+			t.Errorf("got %+v, want the game stopped without a winner", stopped)
+		}
+	})
+
+	t.Run("it plays every game separately", func(t *testing.T) {
+		var games []*tests.GameSpy
+		manager := session.NewManager(func() engine.Game {
+			game := &tests.GameSpy{}
+			games = append(games, game)
+			return game
+		}, newClock())
+
+		first, second := manager.Create().ID, manager.Create().ID
+		_, err := manager.Start(second, 3, nil, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, err)
+		_, err = manager.Finish(ctx, second, "Cleo", false)
+		tests.AssertNoError(t, err)
+
+		if games[0].StartCalled() || !games[1].StartCalled() {
+			t.Error("the wrong game was started")
+		}
+
+		listed := manager.List()
+		if len(listed) != 2 || listed[0].ID != first || listed[1].State != session.Finished {
+			t.Errorf("got games %+v, want the waiting game then the finished one", listed)
+		}
+	})
+
+	t.Run("it lets the player reconnect with the token", func(t *testing.T) {
+		game := &tests.GameSpy{Level: &engine.BlindLevel{SmallBlind: 100, BigBlind: 200}}
+		clock := newClock()
+		manager := session.NewManager(tests.NewGame(game), clock)
+		id := manager.Create().ID
+		first := &bytes.Buffer{}
+		_, err := manager.Start(id, 5, nil, engine.StandardBlinds(), first)
+		tests.AssertNoError(t, err)
+
+		_, err = manager.Disconnect(id, first)
+		tests.AssertNoError(t, err)
+
+		_, err = manager.Reconnect(id, "not the token", io.Discard)
+		assertError(t, err, session.ErrBadToken)
+
+		token, err := manager.Token(id)
+		tests.AssertNoError(t, err)
+		got, err := manager.Reconnect(id, token, io.Discard)
+		tests.AssertNoError(t, err)
+
+		assertState(t, got, session.Running)
+		if got.Level == nil || *got.Level != *game.Level {
+			t.Errorf("got level %v, want %v", got.Level, game.Level)
+		}
+
+		clock.Advance(session.ReconnectGrace)
+		if game.StopCalled() {
+			t.Error("the game was stopped after the player reconnected")
+		}
+	})
+
+	t.Run("it stops games nobody reconnected to in time", func(t *testing.T) {
+		game := &tests.GameSpy{}
+		clock := newClock()
+		manager := session.NewManager(tests.NewGame(game), clock)
+		id := manager.Create().ID
+		_, err := manager.Start(id, 5, nil, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, err)
+
+		_, err = manager.Disconnect(id, io.Discard)
+		tests.AssertNoError(t, err)
+		clock.Advance(session.ReconnectGrace - time.Second)
+		assertState(t, mustGet(t, manager, id), session.Running)
+
+		clock.Advance(time.Second)
+		assertState(t, mustGet(t, manager, id), session.Finished)
+		if !game.StopCalled() {
+			t.Error("expected the game to be stopped")
+		}
+
+		token, err := manager.Token(id)
+		tests.AssertNoError(t, err)
+		_, err = manager.Reconnect(id, token, io.Discard)
+		assertError(t, err, session.ErrWrongState)
+	})
+
+	t.Run("it stops games their player left before they started", func(t *testing.T) {
+		game := &tests.GameSpy{}
+		manager := session.NewManager(tests.NewGame(game), newClock())
+		player, other := &bytes.Buffer{}, &bytes.Buffer{}
+		id := manager.Host(player).ID
+
+		_, joined, err := manager.Join(id, other)
+		tests.AssertNoError(t, err)
+		if joined {
+			t.Error("someone joined a game another player hosts")
+		}
+		_, err = manager.Start(id, 5, nil, engine.StandardBlinds(), other)
+		assertError(t, err, session.ErrNotPlayer)
+
+		got, err := manager.Disconnect(id, other)
+		tests.AssertNoError(t, err)
+		assertState(t, got, session.Waiting)
+
+		got, err = manager.Disconnect(id, player)
+		tests.AssertNoError(t, err)
+		assertState(t, got, session.Finished)
+	})
+
+	t.Run("it lets the first to join play a game nobody hosts", func(t *testing.T) {
+		manager := session.NewManager(tests.NewGame(&tests.GameSpy{}), newClock())
+		player, other := &bytes.Buffer{}, &bytes.Buffer{}
+		id := manager.Create().ID
+
+		_, joined, err := manager.Join(id, player)
+		tests.AssertNoError(t, err)
+		if !joined {
+			t.Error("the first to join didn't get to play")
+		}
+
+		_, joined, err = manager.Join(id, other)
+		tests.AssertNoError(t, err)
+		if joined {
+			t.Error("someone else joined the game of the player")
+		}
+	})
+
+	t.Run("it passes the game on to everyone watching", func(t *testing.T) {
+		game := &tests.GameSpy{Level: &engine.BlindLevel{SmallBlind: 100, BigBlind: 200}}
+		manager := session.NewManager(tests.NewGame(game), newClock())
+		id := manager.Create().ID
+		tv, phone, left := &watcher{}, &watcher{}, &watcher{}
+
+		for _, w := range []*watcher{tv, phone, left} {
+			_, err := manager.Watch(id, w)
+			tests.AssertNoError(t, err)
+		}
+		_, err := manager.Unwatch(id, left)
+		tests.AssertNoError(t, err)
+
+		_, err = manager.Start(id, 5, nil, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, err)
+		_, err = manager.Finish(ctx, id, "Ruth", false)
+		tests.AssertNoError(t, err)
+
+		for _, w := range []*watcher{tv, phone} {
+			w.waitForStates(t, 2)
+			alerts, states := w.seen()
+			if len(alerts) != 1 || alerts[0].Level != *game.Level {
+				t.Errorf("got alerts %v, want %v", alerts, game.Level)
+			}
+			if len(states) != 2 || states[0] != session.Running || states[1] != session.Finished {
+				t.Errorf("got states %v, want running then finished", states)
+			}
+		}
+		if alerts, states := left.seen(); len(alerts) != 0 || len(states) != 0 {
+			t.Errorf("a watcher that left got alerts %v and states %v", alerts, states)
+		}
+
+		_, err = manager.Watch(id, &watcher{})
+		assertError(t, err, session.ErrWrongState)
+	})
+
+	t.Run("it forgets watchers that went away", func(t *testing.T) {
+		game := &tests.GameSpy{Level: &engine.BlindLevel{SmallBlind: 100, BigBlind: 200}}
+		manager := session.NewManager(tests.NewGame(game), newClock())
+		id := manager.Create().ID
+		gone, staying := &watcher{err: errors.New("connection closed")}, &watcher{}
+		for _, w := range []*watcher{gone, staying} {
+			_, err := manager.Watch(id, w)
+			tests.AssertNoError(t, err)
+		}
+
+		_, err := manager.Start(id, 5, nil, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, err)
+		staying.waitForStates(t, 1)
+		_, err = manager.Pause(id)
+		tests.AssertNoError(t, err)
+		staying.waitForStates(t, 2)
+
+		if writes := gone.writeCount(); writes != 1 {
+			t.Errorf("got %d writes to a watcher that went away, want 1", writes)
+		}
+	})
+
+	t.Run("it doesn't wait for watchers too slow to keep up", func(t *testing.T) {
+		game := &tests.GameSpy{Level: &engine.BlindLevel{SmallBlind: 100, BigBlind: 200}}
+		manager := session.NewManager(tests.NewGame(game), newClock())
+		id := manager.Create().ID
+		slow, fast := &watcher{held: make(chan struct{})}, &watcher{}
+		defer close(slow.held)
+		for _, w := range []*watcher{slow, fast} {
+			_, err := manager.Watch(id, w)
+			tests.AssertNoError(t, err)
+		}
+
+		_, err := manager.Start(id, 5, nil, engine.StandardBlinds(), io.Discard)
+		tests.AssertNoError(t, err)
+		fast.waitForStates(t, 1)
+		for i := range 20 {
+			_, err = manager.Pause(id)
+			tests.AssertNoError(t, err)
+			_, err = manager.Resume(id)
+			tests.AssertNoError(t, err)
+			fast.waitForStates(t, 2*i+3)
+		}
+	})
+
+	t.Run("it forgets games some time after they finished", func(t *testing.T) {
+		clock := newClock()
+		manager := session.NewManager(tests.NewGame(&tests.GameSpy{}), clock)
+		id := manager.Create().ID
+		_, err := manager.Stop(id)
+		tests.AssertNoError(t, err)
+
+		clock.Advance(session.FinishedRetention + time.Minute)
+		manager.Create()
+
+		_, err = manager.Get(id)
+		assertError(t, err, session.ErrGameNotFound)
+		if got := len(manager.List()); got != 1 {
+			t.Errorf("got %d games, want 1", got)
+		}
+	})
+}
+
+func newClock() *tests.ManualClock {
+	return tests.NewManualClock(time.Date(2026, time.March, 14, 19, 0, 0, 0, time.UTC))
+}
+
+type watcher struct {
+	io.Writer
+	// held holds up every write until it is closed, if it's set.
+	held chan struct{}
+	err  error
+
+	lock   sync.Mutex
+	alerts []engine.BlindAlert
+	states []session.State
+	writes int
+}
+
+func (w *watcher) WriteBlind(alert engine.BlindAlert) error {
+	w.wait()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.writes++
+	w.alerts = append(w.alerts, alert)
+	return w.err
+}
+
+func (w *watcher) WriteState(game session.Session) error {
+	w.wait()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.writes++
+	w.states = append(w.states, game.State)
+	return w.err
+}
+
+func (w *watcher) wait() {
+	if w.held != nil {
+		<-w.held
+	}
+}
+
+func (w *watcher) seen() ([]engine.BlindAlert, []session.State) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return slices.Clone(w.alerts), slices.Clone(w.states)
+}
+
+func (w *watcher) writeCount() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.writes
+}
+
+// waitForStates waits for the watcher to be told about at least count
+// states, as it is written to from a goroutine of its own.
+func (w *watcher) waitForStates(t testing.TB, count int) {
+	t.Helper()
+
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if _, states := w.seen(); len(states) >= count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	_, states := w.seen()
+	t.Fatalf("got states %v, want at least %d", states, count)
+}
+
+func mustGet(t testing.TB, manager *session.Manager, id string) session.Session {
+	t.Helper()
+
+	game, err := manager.Get(id)
+	tests.AssertNoError(t, err)
+	return game
+}
+
+func assertState(t testing.TB, got session.Session, want session.State) {
+	t.Helper()
+
+	if got.State != want {
+		t.Errorf("got game %s, want %s", got.State, want)
+	}
+}
+
+func assertError(t testing.TB, got, want error) {
+	t.Helper()
+
+	if !errors.Is(got, want) {
+		t.Errorf("got error %v, want %v", got, want)
+	}
+}
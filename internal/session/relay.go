@@ -0,0 +1,255 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// StateWriter is a watcher that wants to hear how the game changes, not only
+// its blinds.
+type StateWriter interface {
+	WriteState(game Session) error
+}
+
+// relay passes on what a game alerts to whoever is playing it right now and
+// to everyone watching, remembering the last alert for those who join later.
+// Nobody may be listening for a while, like when a player reconnects, and the
+// game goes on regardless. Everyone is written to from an outbox of their
+// own, so a slow connection holds up neither the game nor the others.
+type relay struct {
+	lock sync.Mutex
+	to   io.Writer
+	// player passes alerts on to to, nil once to can't be written to anymore.
+	player   *outbox
+	watchers []*outbox
+	last     *engine.BlindAlert
+}
+
+func (r *relay) Write(p []byte) (int, error) {
+	// the game may reuse p once this returns
+	p = slices.Clone(p)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.send(func(w io.Writer) error {
+		_, err := w.Write(p)
+		return err
+	}, false)
+
+	return len(p), nil
+}
+
+func (r *relay) WriteBlind(alert engine.BlindAlert) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.last = &alert
+	r.send(func(w io.Writer) error {
+		return writeBlind(w, alert)
+	}, false)
+
+	return nil
+}
+
+// changed tells the watchers who want to know about it how the game is now.
+func (r *relay) changed(game Session) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.send(func(w io.Writer) error {
+		if sw, ok := w.(StateWriter); ok {
+			return sw.WriteState(game)
+		}
+		return nil
+	}, true)
+}
+
+// send queues write for the watchers, and for the player unless only
+// watchers want it. Those too slow to keep up are dropped. r.lock must be
+// held.
+func (r *relay) send(write func(io.Writer) error, watchersOnly bool) {
+	if r.player != nil && !watchersOnly && !r.player.post(write) {
+		r.drop(errSlow)
+	}
+
+	r.watchers = slices.DeleteFunc(r.watchers, func(o *outbox) bool {
+		if o.post(write) {
+			return false
+		}
+
+		dropWatcher(o, errSlow)
+		return true
+	})
+}
+
+// lost forgets o, whose last write failed with err.
+func (r *relay) lost(o *outbox, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if o == r.player {
+		r.drop(err)
+		return
+	}
+
+	r.watchers = slices.DeleteFunc(r.watchers, func(watcher *outbox) bool {
+		if watcher != o {
+			return false
+		}
+
+		dropWatcher(o, err)
+		return true
+	})
+}
+
+// attach makes to the one alerts are passed on to.
+func (r *relay) attach(to io.Writer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.player != nil {
+		r.player.close()
+	}
+	r.to = to
+	r.player = newOutbox(to, r.lost)
+}
+
+// claim attaches to unless someone else is attached, reporting whether to is.
+func (r *relay) claim(to io.Writer) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	switch r.to {
+	case to:
+		return true
+	case nil:
+		r.to = to
+		r.player = newOutbox(to, r.lost)
+		return true
+	default:
+		return false
+	}
+}
+
+// detach stops passing on alerts to to, reporting whether it was attached.
+func (r *relay) detach(to io.Writer) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.to != to {
+		return false
+	}
+
+	if r.player != nil {
+		r.player.close()
+	}
+	r.to = nil
+	r.player = nil
+	return true
+}
+
+func (r *relay) watch(w io.Writer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.watchers = append(r.watchers, newOutbox(w, r.lost))
+}
+
+func (r *relay) unwatch(w io.Writer) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.watchers = slices.DeleteFunc(r.watchers, func(o *outbox) bool {
+		if o.to != w {
+			return false
+		}
+
+		o.close()
+		return true
+	})
+}
+
+func (r *relay) level() *engine.BlindLevel {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.last == nil {
+		return nil
+	}
+
+	level := r.last.Level
+	return &level
+}
+
+func (r *relay) drop(err error) {
+	log.Println("couldn't pass on the blinds, waiting for the player to reconnect: ", err)
+	r.player.close()
+	r.player = nil
+}
+
+// outboxSize is how many writes an outbox holds before its writer is taken
+// for too slow to keep up.
+const outboxSize = 16
+
+var errSlow = errors.New("too slow to keep up with the game")
+
+// outbox writes to to from a goroutine of its own, in the order the writes
+// were posted, until it is closed or a write fails.
+type outbox struct {
+	to      io.Writer
+	pending chan func(io.Writer) error
+	// lost is told about the write that failed.
+	lost func(o *outbox, err error)
+}
+
+func newOutbox(to io.Writer, lost func(o *outbox, err error)) *outbox {
+	o := &outbox{to: to, pending: make(chan func(io.Writer) error, outboxSize), lost: lost}
+	go o.run()
+	return o
+}
+
+// post queues write without waiting, reporting false if the outbox is full.
+func (o *outbox) post(write func(io.Writer) error) bool {
+	select {
+	case o.pending <- write:
+		return true
+	default:
+		return false
+	}
+}
+
+// close lets the outbox finish the writes posted, then stop. Nothing may be
+// posted to it afterwards.
+func (o *outbox) close() {
+	close(o.pending)
+}
+
+func (o *outbox) run() {
+	for write := range o.pending {
+		if err := write(o.to); err != nil {
+			o.lost(o, err)
+			return
+		}
+	}
+}
+
+func writeBlind(to io.Writer, alert engine.BlindAlert) error {
+	if w, ok := to.(engine.BlindWriter); ok {
+		return w.WriteBlind(alert)
+	}
+
+	_, err := fmt.Fprintln(to, alert.Level)
+	return err
+}
+
+func dropWatcher(o *outbox, err error) {
+	log.Println("couldn't pass on the game to a watcher, dropping it: ", err)
+	o.close()
+}
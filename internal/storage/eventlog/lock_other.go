@@ -0,0 +1,11 @@
+//go:build !unix
+
+package eventlog
+
+import "os"
+
+// tryLock is a no-op where advisory locks aren't available; two processes
+// sharing the log can overwrite each other's events.
+func tryLock(_ *os.File) error {
+	return nil
+}
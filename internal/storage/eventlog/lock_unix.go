@@ -0,0 +1,25 @@
+//go:build unix
+
+package eventlog
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock takes an exclusive advisory lock on file, failing with
+// ErrLogLocked rather than waiting if another process holds it.
+func tryLock(file *os.File) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB) // #nosec G115 -- file descriptors fit in an int
+		switch {
+		case errors.Is(err, syscall.EINTR):
+			continue
+		case errors.Is(err, syscall.EWOULDBLOCK):
+			return ErrLogLocked
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,570 @@
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// ErrLogLocked is returned opening a log another process has open, as only
+// one process can append to it.
+var ErrLogLocked = errors.New("the event log is open in another process")
+
+// DefaultSnapshotEvery is how many events are appended to the log before the
+// league is snapshotted and the log compacted.
+const DefaultSnapshotEvery = 100
+
+const (
+	// EventWin is a bare win, logged before whole matches were recorded.
+	EventWin   = "win"
+	EventMatch = "match"
+	// EventSeason closes a season, archiving the league and starting it
+	// over.
+	EventSeason = "season"
+	// EventCorrection corrects a result, along with the audit entry
+	// recording it.
+	EventCorrection = "correction"
+)
+
+// Event is a single line of the log.
+type Event struct {
+	Seq        int64              `json:"seq"`
+	Type       string             `json:"type"`
+	Player     string             `json:"player,omitempty"`
+	Match      *engine.Match      `json:"match,omitempty"`
+	Season     *engine.Season     `json:"season,omitempty"`
+	Correction *engine.AuditEntry `json:"correction,omitempty"`
+	At         time.Time          `json:"at"`
+}
+
+type snapshot struct {
+	Seq     int64               `json:"seq"`
+	League  engine.League       `json:"league"`
+	Matches []engine.Match      `json:"matches"`
+	Seasons []engine.Season     `json:"seasons"`
+	Audit   []engine.AuditEntry `json:"audit,omitempty"`
+}
+
+// PlayerStore appends every match, closed season and correction to a
+// JSON-lines log and rebuilds the league by replaying it. Every snapshotEvery
+// events the league is snapshotted and the replayed events are moved from the
+// log to an archive, so startup only replays what happened since the last
+// snapshot while the full history is kept.
+type PlayerStore struct {
+	log           logFile
+	lockFile      *os.File
+	path          string
+	league        engine.League
+	matches       []engine.Match
+	seasons       []engine.Season
+	audit         []engine.AuditEntry
+	seq           int64
+	pending       int
+	snapshotEvery int
+	lock          sync.RWMutex
+	changes       engine.Changes
+}
+
+// logFile is the open event log, an *os.File outside of tests.
+type logFile interface {
+	io.ReadWriteSeeker
+	io.ReaderAt
+	io.Closer
+	Stat() (fs.FileInfo, error)
+	Sync() error
+	Truncate(size int64) error
+}
+
+func PlayerStoreFromFile(path string) (*PlayerStore, func(), error) {
+	store, err := NewPlayerStore(path, DefaultSnapshotEvery)
+	if err != nil {
+		return nil, nil, fmt.Errorf("problem creating event log store, %v", err)
+	}
+
+	closeFunc := func() {
+		if err := store.Close(); err != nil {
+			log.Printf("couldn't close the %s: %v", path, err)
+		}
+	}
+
+	return store, closeFunc, nil
+}
+
+func NewPlayerStore(path string, snapshotEvery int) (*PlayerStore, error) {
+	if snapshotEvery <= 0 {
+		snapshotEvery = DefaultSnapshotEvery
+	}
+
+	lockFile, err := os.OpenFile(lockPath(path), os.O_RDWR|os.O_CREATE, 0o600) // #nosec G304 -- derived from the database path
+	if err != nil {
+		return nil, fmt.Errorf("problem opening lock file for %s, %v", path, err)
+	}
+
+	// the lock is held until the store is closed, and taken before anything
+	// is read so no other process appends in between
+	if err := tryLock(lockFile); err != nil {
+		_ = lockFile.Close()
+		return nil, fmt.Errorf("couldn't lock %s: %w", path, err)
+	}
+
+	store := &PlayerStore{
+		lockFile:      lockFile,
+		path:          path,
+		league:        engine.League{},
+		snapshotEvery: snapshotEvery,
+	}
+
+	if err := store.loadSnapshot(); err != nil {
+		_ = lockFile.Close()
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) // #nosec G304 -- path is the configured database
+	if err != nil {
+		_ = lockFile.Close()
+		return nil, fmt.Errorf("problem opening %s %v", path, err)
+	}
+	store.log = file
+
+	if err := store.replay(); err != nil {
+		_ = file.Close()
+		_ = lockFile.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Close closes the log, letting other processes open it.
+func (s *PlayerStore) Close() error {
+	return errors.Join(s.log.Close(), s.lockFile.Close())
+}
+
+func (s *PlayerStore) GetLeague(ctx context.Context) (engine.League, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.league.Sorted(), nil
+}
+
+func (s *PlayerStore) GetPlayerScore(ctx context.Context, name string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if player := s.league.Find(name); player != nil {
+		return player.Wins, nil
+	}
+
+	return 0, nil
+}
+
+func (s *PlayerStore) GetMatches(ctx context.Context) ([]engine.Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return append([]engine.Match(nil), s.matches...), nil
+}
+
+func (s *PlayerStore) RecordWin(ctx context.Context, name string) error {
+	return s.RecordMatch(ctx, engine.ManualWin(name, time.Now().UTC()))
+}
+
+func (s *PlayerStore) RecordMatch(ctx context.Context, match engine.Match) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.record(Event{Seq: s.seq + 1, Type: EventMatch, Match: &match, At: time.Now().UTC()})
+}
+
+func (s *PlayerStore) GetSeasons(ctx context.Context) ([]engine.Season, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return append([]engine.Season(nil), s.seasons...), nil
+}
+
+func (s *PlayerStore) CloseSeason(ctx context.Context, id string, at time.Time) (engine.Season, error) {
+	if err := ctx.Err(); err != nil {
+		return engine.Season{}, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	season, err := engine.CloseSeason(id, s.seasons, engine.FirstPlayed(s.matches), s.league, at)
+	if err != nil {
+		return engine.Season{}, err
+	}
+
+	return season, s.record(Event{Seq: s.seq + 1, Type: EventSeason, Season: &season, At: time.Now().UTC()})
+}
+
+func (s *PlayerStore) Correct(ctx context.Context, correction engine.Correction, by string, at time.Time) (engine.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	entry, err := engine.Correct(correction, s.matches, s.league, s.seasons, by, at, len(s.audit)+1)
+	if err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	return entry, s.record(Event{Seq: s.seq + 1, Type: EventCorrection, Correction: &entry, At: time.Now().UTC()})
+}
+
+func (s *PlayerStore) GetAudit(ctx context.Context) ([]engine.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return append([]engine.AuditEntry(nil), s.audit...), nil
+}
+
+// Subscribe tells when a match is recorded, a season closed or a result
+// corrected.
+func (s *PlayerStore) Subscribe() (<-chan struct{}, func()) {
+	return s.changes.Subscribe()
+}
+
+// record appends event to the log and applies it, compacting the log when
+// it's due. It must be called holding the lock.
+func (s *PlayerStore) record(event Event) error {
+	if err := s.append(event); err != nil {
+		return fmt.Errorf("couldn't append to the event log: %w", err)
+	}
+
+	s.apply(event)
+	s.pending++
+	s.changes.Notify()
+
+	if s.pending >= s.snapshotEvery {
+		// the event is already durable in the log, compaction can be
+		// retried on the next one
+		if err := s.compact(); err != nil {
+			log.Printf("couldn't compact the event log: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// History returns every event recorded so far, oldest first.
+func (s *PlayerStore) History() ([]Event, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	archived, err := readEvents(archivePath(s.path))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	current, err := readEvents(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var history []Event
+	var last int64
+	for _, event := range append(archived, current...) {
+		// an interrupted compaction can archive the same events twice
+		if event.Seq <= last {
+			continue
+		}
+		history = append(history, event)
+		last = event.Seq
+	}
+
+	return history, nil
+}
+
+func (s *PlayerStore) apply(event Event) {
+	s.seq = event.Seq
+
+	switch event.Type {
+	case EventWin:
+		s.league = s.league.AddWin(event.Player)
+	case EventMatch:
+		if event.Match != nil {
+			s.league = s.league.AddWin(event.Match.Winner)
+			s.matches = append(s.matches, *event.Match)
+		}
+	case EventSeason:
+		if event.Season != nil {
+			s.seasons = append(s.seasons, *event.Season)
+			s.league = engine.League{}
+		}
+	case EventCorrection:
+		if event.Correction != nil {
+			s.matches, s.league = event.Correction.Apply(s.matches, s.league)
+			s.audit = append(s.audit, *event.Correction)
+		}
+	}
+}
+
+// append writes event at the end of the log. A write or sync that fails is
+// cut off the log again, so the next event doesn't follow a torn line.
+func (s *PlayerStore) append(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	end, err := s.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("couldn't get the end of the log: %w", err)
+	}
+
+	_, err = s.log.Write(append(line, '\n'))
+	if err == nil {
+		err = s.log.Sync()
+	}
+	if err != nil {
+		return errors.Join(err, s.rollBack(end))
+	}
+
+	return nil
+}
+
+// rollBack truncates the log to end, where the next event is appended.
+func (s *PlayerStore) rollBack(end int64) error {
+	if err := s.log.Truncate(end); err != nil {
+		return fmt.Errorf("couldn't cut a failed event off the log: %w", err)
+	}
+
+	if _, err := s.log.Seek(end, io.SeekStart); err != nil {
+		return fmt.Errorf("couldn't set an offset in a file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PlayerStore) loadSnapshot() error {
+	data, err := os.ReadFile(snapshotPath(s.path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("problem parsing snapshot, %v", err)
+	}
+
+	if snap.League != nil {
+		s.league = snap.League
+	}
+	s.matches = snap.Matches
+	s.seasons = snap.Seasons
+	s.audit = snap.Audit
+	s.seq = snap.Seq
+
+	return nil
+}
+
+// replay applies the events logged after the snapshot. A torn last line left
+// by a crash mid-append is cut off the log.
+func (s *PlayerStore) replay() error {
+	if _, err := s.log.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("couldn't set an offset in a file: %w", err)
+	}
+
+	reader := bufio.NewReader(s.log)
+	var offset int64
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				log.Printf("dropping torn event at the end of %s", s.path)
+				if err := s.log.Truncate(offset); err != nil {
+					return fmt.Errorf("couldn't truncate torn event: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't read event log: %w", err)
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return fmt.Errorf("problem parsing event at offset %d, %v", offset, err)
+		}
+		offset += int64(len(line))
+
+		if event.Seq <= s.seq {
+			continue
+		}
+		s.apply(event)
+		s.pending++
+	}
+
+	_, err := s.log.Seek(offset, io.SeekStart)
+	return err
+}
+
+// compact snapshots the league, moves the logged events to the archive and
+// empties the log. Each step is safe to repeat if a crash interrupts it.
+func (s *PlayerStore) compact() error {
+	data, err := json.Marshal(snapshot{Seq: s.seq, League: s.league, Matches: s.matches, Seasons: s.seasons, Audit: s.audit})
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(snapshotPath(s.path), data); err != nil {
+		return err
+	}
+
+	if err := s.archive(); err != nil {
+		return err
+	}
+
+	if err := s.log.Truncate(0); err != nil {
+		return fmt.Errorf("couldn't truncate event log: %w", err)
+	}
+	if _, err := s.log.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("couldn't set an offset in a file: %w", err)
+	}
+
+	s.pending = 0
+	return s.log.Sync()
+}
+
+// archive appends the logged events to the archive. It reads the log through
+// its own offset so a failure doesn't move where the next event is appended.
+func (s *PlayerStore) archive() error {
+	info, err := s.log.Stat()
+	if err != nil {
+		return fmt.Errorf("couldn't stat event log: %w", err)
+	}
+
+	path := archivePath(s.path)
+	archive, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600) // #nosec G304 -- derived from the database path
+	if err != nil {
+		return fmt.Errorf("couldn't open archive %s: %w", path, err)
+	}
+
+	if _, err := io.Copy(archive, io.NewSectionReader(s.log, 0, info.Size())); err != nil {
+		_ = archive.Close()
+		return fmt.Errorf("couldn't archive events: %w", err)
+	}
+
+	if err := archive.Sync(); err != nil {
+		_ = archive.Close()
+		return fmt.Errorf("couldn't sync archive: %w", err)
+	}
+
+	return archive.Close()
+}
+
+func readEvents(path string) ([]Event, error) {
+	file, err := os.Open(path) // #nosec G304 -- derived from the database path
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("couldn't close the %s: %v", path, err)
+		}
+	}()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// only the last line can be torn, and it holds no recorded win
+			break
+		}
+		events = append(events, event)
+	}
+
+	return events, scanner.Err()
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("couldn't write %s: %w", tmpPath, err)
+	}
+
+	tmp, err := os.Open(tmpPath) // #nosec G304 -- derived from the database path
+	if err != nil {
+		return fmt.Errorf("couldn't open %s: %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("couldn't sync %s: %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("couldn't replace %s: %w", path, err)
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if err := dir.Sync(); err != nil {
+		_ = dir.Close()
+		return err
+	}
+
+	return dir.Close()
+}
+
+func snapshotPath(path string) string {
+	return path + ".snapshot"
+}
+
+func archivePath(path string) string {
+	return path + ".archive"
+}
+
+func lockPath(path string) string {
+	return path + ".lock"
+}
@@ -0,0 +1,221 @@
+package eventlog
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestEventLogStore(t *testing.T) {
+	t.Run("rebuilds the league by replaying the log", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "game.log")
+
+		store := mustOpenStore(t, path, 10)
+		tests.AssertRecordWin(t, store, "Chris")
+		tests.AssertRecordWin(t, store, "Cleo")
+		tests.AssertRecordWin(t, store, "Chris")
+		tests.AssertNoError(t, store.Close())
+
+		reopened := mustOpenStore(t, path, 10)
+		defer reopened.Close()
+
+		want := engine.League{
+			{Name: "Chris", Wins: 2},
+			{Name: "Cleo", Wins: 1},
+		}
+
+		tests.AssertStoreLeague(t, reopened, want)
+		tests.AssertPlayerScore(t, reopened, "Chris", 2)
+	})
+
+	t.Run("snapshots and compacts the log but keeps the history", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "game.log")
+
+		store := mustOpenStore(t, path, 2)
+		tests.AssertRecordWin(t, store, "Chris")
+		tests.AssertRecordWin(t, store, "Cleo")
+		tests.AssertRecordWin(t, store, "Chris")
+		tests.AssertNoError(t, store.Close())
+
+		assertLoggedEvents(t, path, 1)
+
+		reopened := mustOpenStore(t, path, 2)
+		defer reopened.Close()
+
+		tests.AssertPlayerScore(t, reopened, "Chris", 2)
+		tests.AssertPlayerScore(t, reopened, "Cleo", 1)
+
+		history, err := reopened.History()
+		tests.AssertNoError(t, err)
+
+		var players []string
+		for _, event := range history {
+			players = append(players, event.Match.Winner)
+		}
+		if got := strings.Join(players, ","); got != "Chris,Cleo,Chris" {
+			t.Errorf("got history %q, want %q", got, "Chris,Cleo,Chris")
+		}
+	})
+
+	t.Run("replays wins logged before matches were recorded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "game.log")
+		log := `{"seq":1,"type":"win","player":"Cleo","at":"2026-01-01T00:00:00Z"}
+`
+		if err := os.WriteFile(path, []byte(log), 0o600); err != nil {
+			t.Fatalf("couldn't write the log: %v", err)
+		}
+
+		store := mustOpenStore(t, path, 10)
+		defer store.Close()
+
+		match := engine.Match{ID: "1", Game: "texas-holdem", Entrants: 3, Winner: "Cleo"}
+		tests.AssertNoError(t, store.RecordMatch(context.Background(), match))
+
+		tests.AssertPlayerScore(t, store, "Cleo", 2)
+		tests.AssertMatches(t, store, []engine.Match{match})
+	})
+
+	t.Run("replays closed seasons", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "game.log")
+
+		store := mustOpenStore(t, path, 10)
+		tests.AssertRecordWin(t, store, "Cleo")
+		_, err := store.CloseSeason(context.Background(), "2026-Q3", time.Now().UTC())
+		tests.AssertNoError(t, err)
+		tests.AssertRecordWin(t, store, "Chris")
+		tests.AssertNoError(t, store.Close())
+
+		reopened := mustOpenStore(t, path, 10)
+		defer reopened.Close()
+
+		tests.AssertStoreLeague(t, reopened, engine.League{{Name: "Chris", Wins: 1}})
+
+		seasons, err := reopened.GetSeasons(context.Background())
+		tests.AssertNoError(t, err)
+		if len(seasons) != 1 || seasons[0].Standings.Find("Cleo") == nil {
+			t.Errorf("got seasons %+v, want one won by Cleo", seasons)
+		}
+	})
+
+	t.Run("replays corrections", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "game.log")
+
+		store := mustOpenStore(t, path, 10)
+		tests.AssertRecordWin(t, store, "Cleo")
+		tests.AssertRecordWin(t, store, "Cleo")
+		_, err := store.Correct(context.Background(), engine.Correction{Action: engine.ActionAdjust, Player: "Cleo", Wins: -1}, "admin", time.Now().UTC())
+		tests.AssertNoError(t, err)
+		tests.AssertNoError(t, store.Close())
+
+		reopened := mustOpenStore(t, path, 10)
+		defer reopened.Close()
+
+		tests.AssertStoreLeague(t, reopened, engine.League{{Name: "Cleo", Wins: 1}})
+
+		audit, err := reopened.GetAudit(context.Background())
+		tests.AssertNoError(t, err)
+		if len(audit) != 1 || audit[0].By != "admin" {
+			t.Errorf("got audit log %+v, want the correction by admin", audit)
+		}
+	})
+
+	t.Run("drops a torn event at the end of the log", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "game.log")
+		log := `{"seq":1,"type":"win","player":"Cleo","at":"2026-01-01T00:00:00Z"}
+{"seq":2,"type":"win","pla`
+		if err := os.WriteFile(path, []byte(log), 0o600); err != nil {
+			t.Fatalf("couldn't write the log: %v", err)
+		}
+
+		store := mustOpenStore(t, path, 10)
+		tests.AssertRecordWin(t, store, "Chris")
+		tests.AssertNoError(t, store.Close())
+
+		reopened := mustOpenStore(t, path, 10)
+		defer reopened.Close()
+
+		tests.AssertPlayerScore(t, reopened, "Cleo", 1)
+		tests.AssertPlayerScore(t, reopened, "Chris", 1)
+		assertLoggedEvents(t, path, 2)
+	})
+}
+
+func TestFailedAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.log")
+
+	store := mustOpenStore(t, path, 10)
+	tests.AssertRecordWin(t, store, "Chris")
+
+	file := store.log
+	store.log = &tornLog{logFile: file}
+	if err := store.RecordWin(context.Background(), "Cleo"); err == nil {
+		t.Fatal("expected an error but didn't get one")
+	}
+
+	store.log = file
+	tests.AssertRecordWin(t, store, "Chris")
+	tests.AssertNoError(t, store.Close())
+
+	reopened := mustOpenStore(t, path, 10)
+	defer reopened.Close()
+
+	tests.AssertPlayerScore(t, reopened, "Chris", 2)
+	tests.AssertPlayerScore(t, reopened, "Cleo", 0)
+	assertLoggedEvents(t, path, 2)
+}
+
+func TestLogLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.log")
+	store := mustOpenStore(t, path, 10)
+
+	if _, err := NewPlayerStore(path, 10); !errors.Is(err, ErrLogLocked) {
+		t.Errorf("got error %v opening the log twice, want %v", err, ErrLogLocked)
+	}
+
+	tests.AssertNoError(t, store.Close())
+	reopened := mustOpenStore(t, path, 10)
+	tests.AssertNoError(t, reopened.Close())
+}
+
+// tornLog writes half of what it's given, as if the disk filled up.
+type tornLog struct {
+	logFile
+}
+
+func (l *tornLog) Write(p []byte) (int, error) {
+	n, err := l.logFile.Write(p[:len(p)/2])
+	if err != nil {
+		return n, err
+	}
+
+	return n, errors.New("disk full")
+}
+
+func mustOpenStore(t *testing.T, path string, snapshotEvery int) *PlayerStore {
+	t.Helper()
+
+	store, err := NewPlayerStore(path, snapshotEvery)
+	if err != nil {
+		t.Fatalf("couldn't open the event log store: %v", err)
+	}
+
+	return store
+}
+
+func assertLoggedEvents(t testing.TB, path string, want int) {
+	t.Helper()
+
+	events, err := readEvents(path)
+	tests.AssertNoError(t, err)
+
+	if len(events) != want {
+		t.Errorf("got %d events in the log, want %d", len(events), want)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package filesystem
+
+import "os"
+
+// flock is a no-op where advisory locks aren't available; external changes
+// are still detected, but two writers can race.
+func flock(_ *os.File, _ bool) error {
+	return nil
+}
+
+func funlock(_ *os.File) error {
+	return nil
+}
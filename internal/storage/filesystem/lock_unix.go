@@ -0,0 +1,29 @@
+//go:build unix
+
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// flock takes an advisory lock on file, shared or exclusive, waiting for any
+// other process holding a conflicting one.
+func flock(file *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	for {
+		err := syscall.Flock(int(file.Fd()), how) // #nosec G115 -- file descriptors fit in an int
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
+
+func funlock(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN) // #nosec G115 -- file descriptors fit in an int
+}
@@ -0,0 +1,186 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// records is the document stored in the database file. Files written before
+// matches were recorded hold only the league as a bare array, which is read
+// as records without any matches.
+type records struct {
+	League  engine.League   `json:"league"`
+	Matches []engine.Match  `json:"matches"`
+	Seasons []engine.Season `json:"seasons"`
+	// Audit is left out of files without any corrections, so they can still
+	// be read by builds from before there were any.
+	Audit []engine.AuditEntry `json:"audit,omitempty"`
+}
+
+func emptyRecords() records {
+	return records{League: engine.League{}, Matches: []engine.Match{}, Seasons: []engine.Season{}}
+}
+
+// withMatch returns a copy of r with match added, leaving r untouched so it
+// stays current if the copy can't be saved.
+func (r records) withMatch(match engine.Match) records {
+	league := append(engine.League{}, r.League...)
+
+	return records{
+		League:  league.AddWin(match.Winner),
+		Matches: append(append([]engine.Match{}, r.Matches...), match),
+		Seasons: r.Seasons,
+		Audit:   r.Audit,
+	}
+}
+
+// withSeason returns a copy of r with season archived and the league
+// started over.
+func (r records) withSeason(season engine.Season) records {
+	return records{
+		League:  engine.League{},
+		Matches: r.Matches,
+		Seasons: append(append([]engine.Season{}, r.Seasons...), season),
+		Audit:   r.Audit,
+	}
+}
+
+// withCorrection returns a copy of r corrected by entry, which is appended to
+// the audit log.
+func (r records) withCorrection(entry engine.AuditEntry) records {
+	matches, league := entry.Apply(r.Matches, r.League)
+
+	return records{
+		League:  league,
+		Matches: matches,
+		Seasons: r.Seasons,
+		Audit:   append(append([]engine.AuditEntry{}, r.Audit...), entry),
+	}
+}
+
+func parseRecords(data []byte) (records, error) {
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("[")) {
+		league, err := engine.NewLeague(bytes.NewReader(data))
+		if err != nil {
+			return records{}, err
+		}
+		parsed := emptyRecords()
+		parsed.League = league
+		return parsed, nil
+	}
+
+	parsed := emptyRecords()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&parsed); err != nil {
+		return records{}, fmt.Errorf("problem parsing records, %v", err)
+	}
+
+	return parsed, nil
+}
+
+// recoverPendingWrite finishes a write that was interrupted after the new
+// contents were fully staged but before they replaced the database. A staged
+// file that doesn't parse was torn mid-write and is discarded.
+func recoverPendingWrite(path string) error {
+	pending := pendingPath(path)
+
+	data, err := os.ReadFile(pending) // #nosec G304 -- derived from the database path
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't read %s: %w", pending, err)
+	}
+
+	if _, err := parseRecords(data); err != nil {
+		log.Printf("discarding torn pending write %s: %v", pending, err)
+		return os.Remove(pending)
+	}
+
+	log.Printf("recovering pending write %s", pending)
+	if err := os.Rename(pending, path); err != nil {
+		return fmt.Errorf("couldn't replace %s: %w", path, err)
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// loadRecords reads the records stored at path. An empty file is initialized
+// with empty records, and a league torn by a writer from before writes were
+// atomic is salvaged up to its last complete player and rewritten.
+func loadRecords(path string, database io.Writer) (records, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is the configured database
+	if err != nil {
+		return records{}, fmt.Errorf("couldn't read %s: %w", path, err)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		if err := json.NewEncoder(database).Encode(emptyRecords()); err != nil {
+			return records{}, fmt.Errorf("problem initializing player db file %v", err)
+		}
+		return emptyRecords(), nil
+	}
+
+	loaded, err := parseRecords(data)
+	if err == nil {
+		return loaded, nil
+	}
+
+	league, salvageErr := salvageLeague(data)
+	if salvageErr != nil {
+		return records{}, err
+	}
+
+	log.Printf("recovered %d players from torn file %s: %v", len(league), path, err)
+	loaded = emptyRecords()
+	loaded.League = league
+	if err := json.NewEncoder(database).Encode(loaded); err != nil {
+		return records{}, fmt.Errorf("couldn't rewrite recovered league: %w", err)
+	}
+
+	return loaded, nil
+}
+
+// salvageLeague decodes players from a truncated league until the first one
+// that is incomplete. Data that is well formed but of the wrong shape isn't a
+// torn write and is reported instead of being dropped.
+func salvageLeague(data []byte) (engine.League, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected a league array, got %v", token)
+	}
+
+	league := engine.League{}
+	for dec.More() {
+		var player engine.Player
+		err := dec.Decode(&player)
+		var syntaxErr *json.SyntaxError
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &syntaxErr) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		league = append(league, player)
+	}
+
+	return league, nil
+}
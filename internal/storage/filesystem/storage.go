@@ -1,121 +1,377 @@
 package filesystem
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"sort"
 	"sync"
+	"time"
 
 	"github.com/oblassov/game-score-server/internal/engine"
 )
 
+// PlayerStore keeps the league and the matches it was tallied from in memory
+// and rewrites the whole file on every match. Other processes may share the
+// file: every operation takes an advisory lock on a sidecar lock file and
+// reloads the league first if the file was changed since this store last read
+// or wrote it, so their wins are never overwritten.
 type PlayerStore struct {
 	database *json.Encoder
-	league   engine.League
-	lock     sync.RWMutex
+	path     string
+	lockFile *os.File
+	version  version
+	records  records
+	lock     sync.Mutex
+	changes  engine.Changes
+}
+
+// version identifies the contents of the database file this store last saw.
+type version struct {
+	info os.FileInfo
+	sum  [sha256.Size]byte
+}
+
+// unchanged reports whether info still describes the file of v. Every write
+// renames a new file into place, so another store's write changes the inode
+// as well as the modification time.
+func (v version) unchanged(info os.FileInfo) bool {
+	return v.info != nil &&
+		os.SameFile(v.info, info) &&
+		v.info.ModTime().Equal(info.ModTime()) &&
+		v.info.Size() == info.Size()
 }
 
 func PlayerStoreFromFile(path string) (*PlayerStore, func(), error) {
 
 	db, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o666)
 	if err != nil {
-		log.Fatalf("problem opening %s %v", path, err)
+		return nil, nil, fmt.Errorf("problem opening %s %v", path, err)
+	}
+
+	store, err := NewPlayerStore(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("problem creating file system store, %v", err)
 	}
 
 	closeFunc := func() {
+		if err := store.Close(); err != nil {
+			log.Printf("couldn't close the lock of %s: %v", path, err)
+		}
 		if err = db.Close(); err != nil {
 			log.Printf("couldn't close the %s: %v", path, err)
 		}
 	}
 
-	store, err := NewPlayerStore(db)
+	return store, closeFunc, nil
+}
+
+func NewPlayerStore(file *os.File) (*PlayerStore, error) {
+	path := file.Name()
+
+	lockFile, err := os.OpenFile(lockPath(path), os.O_RDWR|os.O_CREATE, 0o600)
 	if err != nil {
-		log.Fatalf("problem creating file system store, %v", err)
+		return nil, fmt.Errorf("problem opening lock file for %s, %v", path, err)
 	}
 
-	return store, closeFunc, err
-}
+	store := &PlayerStore{
+		database: json.NewEncoder(&tape{path: path}),
+		path:     path,
+		lockFile: lockFile,
+	}
 
-func NewPlayerStore(file *os.File) (*PlayerStore, error) {
+	err = store.withFileLock(true, func() error {
+		if err := recoverPendingWrite(path); err != nil {
+			return fmt.Errorf("problem recovering player db file %s, %v", path, err)
+		}
 
-	err := initializePlayerDBFile(file)
+		records, err := loadRecords(path, &tape{path: path})
+		if err != nil {
+			return fmt.Errorf("problem loading player store from file %s, %v", path, err)
+		}
+		store.records = records
+
+		_, store.version, err = readDatabase(path)
+		return err
+	})
 
 	if err != nil {
-		return nil, fmt.Errorf("problem initializing player db file %v", err)
+		_ = lockFile.Close()
+		return nil, err
 	}
 
-	league, err := engine.NewLeague(file)
+	return store, nil
 
-	if err != nil {
-		return nil, fmt.Errorf("problem loading player store from file %s, %v", file.Name(), err)
+}
+
+// Close releases the lock file. The database file itself belongs to the
+// caller.
+func (f *PlayerStore) Close() error {
+	return f.lockFile.Close()
+}
+
+func (f *PlayerStore) GetLeague(ctx context.Context) (engine.League, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return &PlayerStore{
-		database: json.NewEncoder(&tape{file}),
-		league:   league,
-	}, nil
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err := f.withFileLock(false, f.reload); err != nil {
+		return nil, fmt.Errorf("couldn't reload the db: %w", err)
+	}
 
+	return f.records.League.Sorted(), nil
 }
 
-func initializePlayerDBFile(file *os.File) error {
-	if _, err := file.Seek(0, io.SeekStart); err != nil {
-		return fmt.Errorf("couldn't set an offset in a file: %w", err)
+func (f *PlayerStore) GetPlayerScore(ctx context.Context, name string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
 
-	info, err := file.Stat()
-	if err != nil {
-		return fmt.Errorf("problem getting file info from file %s, %w", file.Name(), err)
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err := f.withFileLock(false, f.reload); err != nil {
+		return 0, fmt.Errorf("couldn't reload the db: %w", err)
 	}
 
-	if info.Size() == 0 {
-		if _, err := file.WriteString("[]"); err != nil {
-			return fmt.Errorf("couldn't write string: %w", err)
-		}
-		if _, err := file.Seek(0, io.SeekStart); err != nil {
-			return fmt.Errorf("couldn't set an offset in a file when size is 0: %w", err)
+	player := f.records.League.Find(name)
+
+	if player != nil {
+		return player.Wins, nil
+	}
+
+	return 0, nil
+}
+
+func (f *PlayerStore) GetMatches(ctx context.Context) ([]engine.Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err := f.withFileLock(false, f.reload); err != nil {
+		return nil, fmt.Errorf("couldn't reload the db: %w", err)
+	}
+
+	return append([]engine.Match(nil), f.records.Matches...), nil
+}
+
+func (f *PlayerStore) RecordWin(ctx context.Context, name string) error {
+	return f.RecordMatch(ctx, engine.ManualWin(name, time.Now().UTC()))
+}
+
+func (f *PlayerStore) RecordMatch(ctx context.Context, match engine.Match) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	err := f.withFileLock(true, func() error {
+		if err := f.reload(); err != nil {
+			return fmt.Errorf("refusing to overwrite the db: %w", err)
 		}
+
+		return f.save(f.records.withMatch(match))
+	})
+	if err != nil {
+		return err
 	}
 
+	f.changes.Notify()
 	return nil
 }
 
-func (f *PlayerStore) GetLeague() engine.League {
-	f.lock.RLock()
-	defer f.lock.RUnlock()
-	sort.Slice(f.league, func(i, j int) bool {
-		return f.league[i].Wins > f.league[j].Wins
+func (f *PlayerStore) GetSeasons(ctx context.Context) ([]engine.Season, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err := f.withFileLock(false, f.reload); err != nil {
+		return nil, fmt.Errorf("couldn't reload the db: %w", err)
+	}
+
+	return append([]engine.Season(nil), f.records.Seasons...), nil
+}
+
+func (f *PlayerStore) CloseSeason(ctx context.Context, id string, at time.Time) (engine.Season, error) {
+	if err := ctx.Err(); err != nil {
+		return engine.Season{}, err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var season engine.Season
+	err := f.withFileLock(true, func() error {
+		if err := f.reload(); err != nil {
+			return fmt.Errorf("refusing to overwrite the db: %w", err)
+		}
+
+		var err error
+		season, err = engine.CloseSeason(id, f.records.Seasons, engine.FirstPlayed(f.records.Matches), f.records.League, at)
+		if err != nil {
+			return err
+		}
+
+		return f.save(f.records.withSeason(season))
 	})
-	return f.league
+	if err != nil {
+		return engine.Season{}, err
+	}
+
+	f.changes.Notify()
+	return season, nil
 }
 
-func (f *PlayerStore) GetPlayerScore(name string) int {
-	f.lock.RLock()
-	defer f.lock.RUnlock()
+func (f *PlayerStore) Correct(ctx context.Context, correction engine.Correction, by string, at time.Time) (engine.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return engine.AuditEntry{}, err
+	}
 
-	player := f.league.Find(name)
+	f.lock.Lock()
+	defer f.lock.Unlock()
 
-	if player != nil {
-		return player.Wins
+	var entry engine.AuditEntry
+	err := f.withFileLock(true, func() error {
+		if err := f.reload(); err != nil {
+			return fmt.Errorf("refusing to overwrite the db: %w", err)
+		}
+
+		var err error
+		entry, err = engine.Correct(correction, f.records.Matches, f.records.League, f.records.Seasons, by, at, len(f.records.Audit)+1)
+		if err != nil {
+			return err
+		}
+
+		return f.save(f.records.withCorrection(entry))
+	})
+	if err != nil {
+		return engine.AuditEntry{}, err
 	}
 
-	return 0
+	f.changes.Notify()
+	return entry, nil
 }
 
-func (f *PlayerStore) RecordWin(name string) {
+func (f *PlayerStore) GetAudit(ctx context.Context) ([]engine.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	f.lock.Lock()
 	defer f.lock.Unlock()
-	player := f.league.Find(name)
 
-	if player != nil {
-		player.Wins++
-	} else {
-		f.league = append(f.league, engine.Player{Name: name, Wins: 1})
+	if err := f.withFileLock(false, f.reload); err != nil {
+		return nil, fmt.Errorf("couldn't reload the db: %w", err)
+	}
+
+	return append([]engine.AuditEntry(nil), f.records.Audit...), nil
+}
+
+// Subscribe tells when this store records a match, closes a season or
+// corrects a result. What other processes sharing the file change goes
+// unnoticed.
+func (f *PlayerStore) Subscribe() (<-chan struct{}, func()) {
+	return f.changes.Subscribe()
+}
+
+// save writes records to the file and makes them current once they're
+// safely stored. It must be called holding the exclusive file lock.
+func (f *PlayerStore) save(records records) error {
+	if err := f.database.Encode(records); err != nil {
+		return fmt.Errorf("couldn't encode the db: %w", err)
+	}
+	f.records = records
+
+	var err error
+	_, f.version, err = readDatabase(f.path)
+	return err
+}
+
+// withFileLock runs fn holding the lock shared with other processes.
+func (f *PlayerStore) withFileLock(exclusive bool, fn func() error) error {
+	if err := flock(f.lockFile, exclusive); err != nil {
+		return fmt.Errorf("couldn't lock %s: %w", f.lockFile.Name(), err)
+	}
+
+	fnErr := fn()
+
+	if err := funlock(f.lockFile); err != nil {
+		return errors.Join(fnErr, fmt.Errorf("couldn't unlock %s: %w", f.lockFile.Name(), err))
+	}
+
+	return fnErr
+}
+
+// reload picks up changes another process made to the database since this
+// store last read or wrote it. It must be called holding the file lock.
+func (f *PlayerStore) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("couldn't stat %s: %w", f.path, err)
+	}
+
+	if f.version.unchanged(info) {
+		return nil
+	}
+
+	data, current, err := readDatabase(f.path)
+	if err != nil {
+		return err
+	}
+
+	if current.sum != f.version.sum {
+		records, err := parseRecords(data)
+		if err != nil {
+			return fmt.Errorf("%s was changed by another process, %v", f.path, err)
+		}
+		f.records = records
 	}
 
-	if err := f.database.Encode(f.league); err != nil {
-		log.Printf("couldn't encode the db: %v", err)
+	f.version = current
+	return nil
+}
+
+// readDatabase reads the database along with the version it was read at.
+func readDatabase(path string) ([]byte, version, error) {
+	file, err := os.Open(path) // #nosec G304 -- path is the configured database
+	if err != nil {
+		return nil, version{}, fmt.Errorf("couldn't open %s: %w", path, err)
 	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("couldn't close the %s: %v", path, err)
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, version{}, fmt.Errorf("couldn't stat %s: %w", path, err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, version{}, fmt.Errorf("couldn't read %s: %w", path, err)
+	}
+
+	return data, version{info: info, sum: sha256.Sum256(data)}, nil
+}
+
+func lockPath(path string) string {
+	return path + ".lock"
 }
@@ -1,7 +1,12 @@
 package filesystem
 
 import (
+	"context"
+	"errors"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/oblassov/game-score-server/internal/engine"
 	"github.com/oblassov/game-score-server/tests"
@@ -19,7 +24,8 @@ func TestFileSystemStore(t *testing.T) {
 
 		tests.AssertNoError(t, err)
 
-		got := store.GetLeague()
+		got, err := store.GetLeague(context.Background())
+		tests.AssertNoError(t, err)
 		want := []engine.Player{
 			{Name: "Chris", Wins: 33},
 			{Name: "Cleo", Wins: 10},
@@ -28,7 +34,8 @@ func TestFileSystemStore(t *testing.T) {
 		tests.AssertLeague(t, got, want)
 
 		// read again
-		got = store.GetLeague()
+		got, err = store.GetLeague(context.Background())
+		tests.AssertNoError(t, err)
 		tests.AssertLeague(t, got, want)
 	})
 
@@ -43,7 +50,8 @@ func TestFileSystemStore(t *testing.T) {
 
 		tests.AssertNoError(t, err)
 
-		got := store.GetPlayerScore("Chris")
+		got, err := store.GetPlayerScore(context.Background(), "Chris")
+		tests.AssertNoError(t, err)
 		want := 33
 
 		tests.AssertScoreEquals(t, got, want)
@@ -58,11 +66,12 @@ func TestFileSystemStore(t *testing.T) {
 		defer cleanDatabase()
 
 		store, err := NewPlayerStore(database)
-		store.RecordWin("Chris")
+		tests.AssertRecordWin(t, store, "Chris")
 
 		tests.AssertNoError(t, err)
 
-		got := store.GetPlayerScore("Chris")
+		got, err := store.GetPlayerScore(context.Background(), "Chris")
+		tests.AssertNoError(t, err)
 		want := 34
 
 		tests.AssertScoreEquals(t, got, want)
@@ -76,11 +85,12 @@ func TestFileSystemStore(t *testing.T) {
 		defer cleanDatabase()
 
 		store, err := NewPlayerStore(database)
-		store.RecordWin("Pepper")
+		tests.AssertRecordWin(t, store, "Pepper")
 
 		tests.AssertNoError(t, err)
 
-		got := store.GetPlayerScore("Pepper")
+		got, err := store.GetPlayerScore(context.Background(), "Pepper")
+		tests.AssertNoError(t, err)
 		want := 1
 		tests.AssertScoreEquals(t, got, want)
 	})
@@ -103,7 +113,8 @@ func TestFileSystemStore(t *testing.T) {
 
 		tests.AssertNoError(t, err)
 
-		got := store.GetLeague()
+		got, err := store.GetLeague(context.Background())
+		tests.AssertNoError(t, err)
 
 		want := engine.League{
 			{Name: "Chris", Wins: 33},
@@ -113,7 +124,198 @@ func TestFileSystemStore(t *testing.T) {
 		tests.AssertLeague(t, got, want)
 
 		// read again
-		got = store.GetLeague()
+		got, err = store.GetLeague(context.Background())
+		tests.AssertNoError(t, err)
+		tests.AssertLeague(t, got, want)
+	})
+
+	t.Run("recovers the complete players from a torn file", func(t *testing.T) {
+		database, cleanDatabase := tests.CreateTempFile(t, `[
+			{"Name": "Cleo", "Wins": 10},
+			{"Name": "Chris", "Wi`)
+		defer cleanDatabase()
+
+		store, err := NewPlayerStore(database)
+
+		tests.AssertNoError(t, err)
+
+		got, err := store.GetLeague(context.Background())
+		tests.AssertNoError(t, err)
+		want := engine.League{
+			{Name: "Cleo", Wins: 10},
+		}
+
 		tests.AssertLeague(t, got, want)
 	})
+
+	t.Run("finishes a pending write", func(t *testing.T) {
+		database, cleanDatabase := tests.CreateTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		pending := pendingPath(database.Name())
+		if err := os.WriteFile(pending, []byte(`[{"Name": "Cleo", "Wins": 11}]`), 0o600); err != nil {
+			t.Fatalf("couldn't write pending file: %v", err)
+		}
+
+		store, err := NewPlayerStore(database)
+
+		tests.AssertNoError(t, err)
+		tests.AssertPlayerScore(t, store, "Cleo", 11)
+	})
+
+	t.Run("discards a torn pending write", func(t *testing.T) {
+		database, cleanDatabase := tests.CreateTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		pending := pendingPath(database.Name())
+		if err := os.WriteFile(pending, []byte(`[{"Name": "Cleo", "Wi`), 0o600); err != nil {
+			t.Fatalf("couldn't write pending file: %v", err)
+		}
+
+		store, err := NewPlayerStore(database)
+
+		tests.AssertNoError(t, err)
+		tests.AssertPlayerScore(t, store, "Cleo", 10)
+	})
+
+	t.Run("keeps wins across reopening", func(t *testing.T) {
+		database, cleanDatabase := tests.CreateTempFile(t, "")
+		defer cleanDatabase()
+
+		store, err := NewPlayerStore(database)
+		tests.AssertNoError(t, err)
+		tests.AssertRecordWin(t, store, "Pepper")
+
+		reopened, err := NewPlayerStore(database)
+		tests.AssertNoError(t, err)
+
+		tests.AssertPlayerScore(t, reopened, "Pepper", 1)
+	})
+
+	t.Run("two stores on one file don't lose each other's wins", func(t *testing.T) {
+		database, cleanDatabase := tests.CreateTempFile(t, "")
+		defer cleanDatabase()
+
+		webserver := mustOpenStore(t, database.Name())
+		cli := mustOpenStore(t, database.Name())
+
+		var wg sync.WaitGroup
+		for _, store := range []*PlayerStore{webserver, cli} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 25 {
+					tests.AssertRecordWin(t, store, "Pepper")
+				}
+			}()
+		}
+		wg.Wait()
+
+		tests.AssertPlayerScore(t, webserver, "Pepper", 50)
+		tests.AssertPlayerScore(t, cli, "Pepper", 50)
+	})
+
+	t.Run("refuses to overwrite a file it can't read back", func(t *testing.T) {
+		database, cleanDatabase := tests.CreateTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		store := mustOpenStore(t, database.Name())
+
+		edited := `{"not": "a league"}`
+		if err := os.WriteFile(database.Name(), []byte(edited), 0o600); err != nil {
+			t.Fatalf("couldn't edit the db: %v", err)
+		}
+
+		if err := store.RecordWin(context.Background(), "Cleo"); err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+
+		got, _ := os.ReadFile(database.Name())
+		tests.AssertResponseBody(t, string(got), edited)
+	})
+
+	t.Run("doesn't record wins for a cancelled request", func(t *testing.T) {
+		database, cleanDatabase := tests.CreateTempFile(t, "")
+		defer cleanDatabase()
+
+		store := mustOpenStore(t, database.Name())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := store.RecordWin(ctx, "Pepper"); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+
+		tests.AssertPlayerScore(t, store, "Pepper", 0)
+	})
+
+	t.Run("keeps matches alongside a league from an older file", func(t *testing.T) {
+		database, cleanDatabase := tests.CreateTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		store := mustOpenStore(t, database.Name())
+
+		match := engine.Match{
+			ID:             "1",
+			Game:           "texas-holdem",
+			StartedAt:      time.Date(2026, 7, 1, 19, 0, 0, 0, time.UTC),
+			FinishedAt:     time.Date(2026, 7, 1, 21, 0, 0, 0, time.UTC),
+			Entrants:       5,
+			FinishingOrder: []string{"Cleo"},
+			Winner:         "Cleo",
+		}
+		tests.AssertNoError(t, store.RecordMatch(context.Background(), match))
+
+		reopened := mustOpenStore(t, database.Name())
+
+		tests.AssertPlayerScore(t, reopened, "Cleo", 11)
+		tests.AssertMatches(t, reopened, []engine.Match{match})
+	})
+
+	t.Run("closing a season archives the league and starts it over", func(t *testing.T) {
+		database, cleanDatabase := tests.CreateTempFile(t, `[{"Name": "Cleo", "Wins": 10}]`)
+		defer cleanDatabase()
+
+		store := mustOpenStore(t, database.Name())
+
+		at := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+		_, err := store.CloseSeason(context.Background(), "2026-Q3", at)
+		tests.AssertNoError(t, err)
+
+		_, err = store.CloseSeason(context.Background(), "2026-Q3", at)
+		if !errors.Is(err, engine.ErrSeasonExists) {
+			t.Errorf("got %v closing a season twice, want %v", err, engine.ErrSeasonExists)
+		}
+
+		reopened := mustOpenStore(t, database.Name())
+
+		tests.AssertStoreLeague(t, reopened, engine.League{})
+
+		seasons, err := reopened.GetSeasons(context.Background())
+		tests.AssertNoError(t, err)
+		if len(seasons) != 1 {
+			t.Fatalf("got %d seasons, want 1", len(seasons))
+		}
+		tests.AssertLeague(t, seasons[0].Standings, engine.League{{Name: "Cleo", Wins: 10}})
+	})
+}
+
+func mustOpenStore(t *testing.T, path string) *PlayerStore {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("couldn't open %s: %v", path, err)
+	}
+
+	store, err := NewPlayerStore(file)
+	tests.AssertNoError(t, err)
+
+	t.Cleanup(func() {
+		_ = store.Close()
+		_ = file.Close()
+	})
+
+	return store
 }
@@ -1,20 +1,76 @@
 package filesystem
 
 import (
-	"io"
+	"fmt"
 	"os"
+	"path/filepath"
 )
 
+// tape rewrites the whole database file on every Write. The data goes to a
+// temporary file next to the database first, which is synced and then
+// atomically renamed over it, so a crash or a full disk mid-write leaves
+// either the previous contents or the new ones, never a torn file.
 type tape struct {
-	file *os.File
+	path string
 }
 
 func (t *tape) Write(p []byte) (n int, err error) {
-	if err := t.file.Truncate(0); err != nil {
-		return 0, err
+	tmpPath := pendingPath(t.path)
+
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't create %s: %w", tmpPath, err)
+	}
+
+	// the database keeps its mode, rather than taking the one of a new file
+	if info, statErr := os.Stat(t.path); statErr == nil {
+		if err = tmp.Chmod(info.Mode().Perm()); err != nil {
+			_ = tmp.Close()
+			return 0, fmt.Errorf("couldn't keep the mode of %s: %w", t.path, err)
+		}
+	}
+
+	if n, err = tmp.Write(p); err != nil {
+		_ = tmp.Close()
+		return 0, fmt.Errorf("couldn't write %s: %w", tmpPath, err)
+	}
+
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return 0, fmt.Errorf("couldn't sync %s: %w", tmpPath, err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return 0, fmt.Errorf("couldn't close %s: %w", tmpPath, err)
+	}
+
+	if err = os.Rename(tmpPath, t.path); err != nil {
+		return 0, fmt.Errorf("couldn't replace %s: %w", t.path, err)
 	}
-	if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+
+	if err = syncDir(filepath.Dir(t.path)); err != nil {
 		return 0, err
 	}
-	return t.file.Write(p)
+
+	return n, nil
+}
+
+// pendingPath is where a write is staged before it replaces the database.
+func pendingPath(path string) string {
+	return path + ".tmp"
+}
+
+// syncDir makes a rename inside dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir) // #nosec G304 -- dir is the database's own directory
+	if err != nil {
+		return fmt.Errorf("couldn't open directory %s: %w", dir, err)
+	}
+
+	if err := d.Sync(); err != nil {
+		_ = d.Close()
+		return fmt.Errorf("couldn't sync directory %s: %w", dir, err)
+	}
+
+	return d.Close()
 }
@@ -1,7 +1,9 @@
 package filesystem
 
 import (
-	"io"
+	"errors"
+	"io/fs"
+	"os"
 	"testing"
 
 	"github.com/oblassov/game-score-server/tests"
@@ -11,15 +13,12 @@ func TestTape_Write(t *testing.T) {
 	file, clean := tests.CreateTempFile(t, "12345")
 	defer clean()
 
-	tape := &tape{file: file}
+	tape := &tape{path: file.Name()}
 	if _, err := tape.Write([]byte("abc")); err != nil {
 		t.Errorf("couldn't write: %v", err)
 	}
 
-	if _, err := file.Seek(0, io.SeekStart); err != nil {
-		t.Errorf("couldn't set an offset in a file: %v", err)
-	}
-	newFileContents, _ := io.ReadAll(file)
+	newFileContents, _ := os.ReadFile(file.Name())
 
 	got := string(newFileContents)
 	want := "abc"
@@ -27,4 +26,29 @@ func TestTape_Write(t *testing.T) {
 	if got != want {
 		t.Errorf("got %v want %v", got, want)
 	}
+
+	if _, err := os.Stat(pendingPath(file.Name())); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the pending write to be renamed away, got %v", err)
+	}
+}
+
+func TestTape_WriteKeepsMode(t *testing.T) {
+	file, clean := tests.CreateTempFile(t, "12345")
+	defer clean()
+
+	if err := os.Chmod(file.Name(), 0o640); err != nil {
+		t.Fatalf("couldn't change the mode of %s: %v", file.Name(), err)
+	}
+
+	tape := &tape{path: file.Name()}
+	if _, err := tape.Write([]byte("abc")); err != nil {
+		t.Errorf("couldn't write: %v", err)
+	}
+
+	info, err := os.Stat(file.Name())
+	tests.AssertNoError(t, err)
+
+	if got := info.Mode().Perm(); got != 0o640 {
+		t.Errorf("got mode %v, want %v", got, fs.FileMode(0o640))
+	}
 }
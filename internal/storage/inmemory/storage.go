@@ -1,36 +1,150 @@
 package inmemory
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/oblassov/game-score-server/internal/engine"
 )
 
 type PlayerStore struct {
-	store map[string]int
-	lock  sync.RWMutex
+	store   map[string]int
+	matches []engine.Match
+	seasons []engine.Season
+	audit   []engine.AuditEntry
+	lock    sync.RWMutex
+	changes engine.Changes
 }
 
-func (i *PlayerStore) GetPlayerScore(name string) int {
+func (i *PlayerStore) GetPlayerScore(ctx context.Context, name string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
 	i.lock.RLock()
 	defer i.lock.RUnlock()
-	return i.store[name]
+	return i.store[name], nil
+}
+
+func (i *PlayerStore) RecordWin(ctx context.Context, name string) error {
+	return i.RecordMatch(ctx, engine.ManualWin(name, time.Now().UTC()))
 }
 
-func (i *PlayerStore) RecordWin(name string) {
+func (i *PlayerStore) RecordMatch(ctx context.Context, match engine.Match) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	i.lock.Lock()
 	defer i.lock.Unlock()
-	i.store[name]++
+	i.matches = append(i.matches, match)
+	i.store[match.Winner]++
+	i.changes.Notify()
+	return nil
+}
+
+func (i *PlayerStore) GetMatches(ctx context.Context) ([]engine.Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return append([]engine.Match(nil), i.matches...), nil
+}
+
+func (i *PlayerStore) GetLeague(ctx context.Context) (engine.League, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return i.league(), nil
+}
+
+func (i *PlayerStore) GetSeasons(ctx context.Context) ([]engine.Season, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return append([]engine.Season(nil), i.seasons...), nil
+}
+
+func (i *PlayerStore) CloseSeason(ctx context.Context, id string, at time.Time) (engine.Season, error) {
+	if err := ctx.Err(); err != nil {
+		return engine.Season{}, err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	season, err := engine.CloseSeason(id, i.seasons, engine.FirstPlayed(i.matches), i.league(), at)
+	if err != nil {
+		return engine.Season{}, err
+	}
+
+	i.seasons = append(i.seasons, season)
+	i.store = map[string]int{}
+	i.changes.Notify()
+	return season, nil
+}
+
+func (i *PlayerStore) Correct(ctx context.Context, correction engine.Correction, by string, at time.Time) (engine.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	entry, err := engine.Correct(correction, i.matches, i.league(), i.seasons, by, at, len(i.audit)+1)
+	if err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	i.matches, _ = entry.Apply(i.matches, nil)
+	for name, wins := range entry.After.Wins {
+		if wins > 0 {
+			i.store[name] = wins
+		} else {
+			delete(i.store, name)
+		}
+	}
+	i.audit = append(i.audit, entry)
+	i.changes.Notify()
+	return entry, nil
+}
+
+func (i *PlayerStore) GetAudit(ctx context.Context) ([]engine.AuditEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return append([]engine.AuditEntry(nil), i.audit...), nil
+}
+
+// Subscribe tells when a match is recorded, a season closed or a result
+// corrected.
+func (i *PlayerStore) Subscribe() (<-chan struct{}, func()) {
+	return i.changes.Subscribe()
 }
 
-func (i *PlayerStore) GetLeague() engine.League {
-	var league []engine.Player
+// league sorts the players like the other stores. It must be called holding
+// the lock.
+func (i *PlayerStore) league() engine.League {
+	var league engine.League
 
 	for name, wins := range i.store {
 		league = append(league, engine.Player{Name: name, Wins: wins})
 	}
 
-	return league
+	return league.Sorted()
 }
 
 func NewInMemoryPlayerStore() *PlayerStore {
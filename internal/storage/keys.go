@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// keysExt names the file keeping the API keys of a league, next to its
+// database.
+const keysExt = ".keys.json"
+
+// Keys keeps the API keys of a league in a JSON file of their own, whatever
+// the kind of its store. Only their hashes are written.
+type Keys struct {
+	path string
+	lock sync.Mutex
+}
+
+// OpenKeys keeps keys in the file at path, created with the first key.
+func OpenKeys(path string) *Keys {
+	return &Keys{path: path}
+}
+
+// KeysPath is where the API keys of the store of the given kind at dbPath are
+// kept.
+func KeysPath(kind, dbPath string) string {
+	return strings.TrimSuffix(dbPath, extensions[kind]) + keysExt
+}
+
+func (k *Keys) AddKey(ctx context.Context, key engine.APIKey) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	keys, err := k.read()
+	if err != nil {
+		return err
+	}
+
+	return k.write(append(keys, key))
+}
+
+func (k *Keys) ListKeys(ctx context.Context) ([]engine.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	return k.read()
+}
+
+func (k *Keys) RevokeKey(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	keys, err := k.read()
+	if err != nil {
+		return err
+	}
+
+	kept := slices.DeleteFunc(keys, func(key engine.APIKey) bool {
+		return key.ID == id
+	})
+	if len(kept) == len(keys) {
+		return fmt.Errorf("%w: %s", engine.ErrKeyNotFound, id)
+	}
+
+	return k.write(kept)
+}
+
+func (k *Keys) read() ([]engine.APIKey, error) {
+	var keys []engine.APIKey
+	if err := readJSON(k.path, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (k *Keys) write(keys []engine.APIKey) error {
+	return writeJSON(k.path, keys)
+}
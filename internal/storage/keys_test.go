@@ -0,0 +1,69 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/storage"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestKeys(t *testing.T) {
+	ctx := context.Background()
+	path := storage.KeysPath(storage.KindFile, filepath.Join(t.TempDir(), "game.db.json"))
+
+	key, secret, err := engine.NewAPIKey("scorekeeper", engine.RoleScorekeeper, time.Now().UTC())
+	tests.AssertNoError(t, err)
+
+	t.Run("it keeps keys across openings", func(t *testing.T) {
+		tests.AssertNoError(t, storage.OpenKeys(path).AddKey(ctx, key))
+
+		got, err := engine.CheckAPIKey(ctx, storage.OpenKeys(path), secret)
+		tests.AssertNoError(t, err)
+		if got.ID != key.ID {
+			t.Errorf("got key %+v, want %+v", got, key)
+		}
+	})
+
+	t.Run("it revokes keys", func(t *testing.T) {
+		keys := storage.OpenKeys(path)
+		tests.AssertNoError(t, keys.RevokeKey(ctx, key.ID))
+
+		if _, err := engine.CheckAPIKey(ctx, keys, secret); !errors.Is(err, engine.ErrBadKey) {
+			t.Errorf("got error %v for a revoked key, want %v", err, engine.ErrBadKey)
+		}
+		if err := keys.RevokeKey(ctx, key.ID); !errors.Is(err, engine.ErrKeyNotFound) {
+			t.Errorf("got error %v revoking it twice, want %v", err, engine.ErrKeyNotFound)
+		}
+	})
+
+	t.Run("it keeps the keys of every league apart", func(t *testing.T) {
+		leagues := mustOpenLeagues(t, storage.KindSQLite, t.TempDir())
+		tests.AssertNoError(t, leagues.CreateLeague(ctx, "tuesday"))
+		tests.AssertNoError(t, leagues.CreateLeague(ctx, "friday"))
+
+		tuesday, err := leagues.Keys(ctx, "tuesday")
+		tests.AssertNoError(t, err)
+		tests.AssertNoError(t, tuesday.AddKey(ctx, key))
+
+		friday, err := leagues.Keys(ctx, "friday")
+		tests.AssertNoError(t, err)
+		if _, err := engine.CheckAPIKey(ctx, friday, secret); !errors.Is(err, engine.ErrBadKey) {
+			t.Errorf("got error %v for a key of tuesday, want %v", err, engine.ErrBadKey)
+		}
+
+		if _, err := leagues.Keys(ctx, "sunday"); !errors.Is(err, engine.ErrLeagueNotFound) {
+			t.Errorf("got error %v for sunday, want %v", err, engine.ErrLeagueNotFound)
+		}
+
+		ids, err := leagues.ListLeagues(ctx)
+		tests.AssertNoError(t, err)
+		if len(ids) != 2 {
+			t.Errorf("got leagues %v, want the keys not taken for one", ids)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// extensions name the database files of every kind of store, so a directory
+// holding leagues can tell their databases from the files kept beside them.
+var extensions = map[string]string{
+	KindFile:     ".db.json",
+	KindEventLog: ".log",
+	KindSQLite:   ".db",
+}
+
+// Leagues keeps every league in a database of its own in one directory,
+// opening them as they are first used.
+type Leagues struct {
+	kind string
+	dir  string
+	ext  string
+	open map[string]engine.PlayerStore
+	// closers close every opened store.
+	closers []func()
+	lock    sync.Mutex
+}
+
+// NewLeagues keeps leagues in stores of the given kind under dir, creating
+// dir if it doesn't exist.
+func NewLeagues(kind, dir string) (*Leagues, error) {
+	ext, ok := extensions[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown store %q, want one of %s, %s or %s", kind, KindFile, KindEventLog, KindSQLite)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("couldn't create the leagues directory %s: %w", dir, err)
+	}
+
+	return &Leagues{kind: kind, dir: dir, ext: ext, open: map[string]engine.PlayerStore{}}, nil
+}
+
+func (l *Leagues) CreateLeague(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := engine.ValidateLeagueID(id); err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	path := l.path(id)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600) // #nosec G304 -- id is validated
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", engine.ErrLeagueExists, id)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't create league %s: %w", id, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("couldn't create league %s: %w", id, err)
+	}
+
+	if _, err := l.openStore(id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (l *Leagues) ListLeagues(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list the leagues in %s: %w", l.dir, err)
+	}
+
+	ids := []string{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), l.ext)
+		if !ok || entry.IsDir() || engine.ValidateLeagueID(id) != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Store returns the store of league id, or engine.ErrLeagueNotFound if it
+// wasn't created.
+func (l *Leagues) Store(ctx context.Context, id string) (engine.PlayerStore, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if engine.ValidateLeagueID(id) != nil {
+		return nil, fmt.Errorf("%w: %s", engine.ErrLeagueNotFound, id)
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if store, ok := l.open[id]; ok {
+		return store, nil
+	}
+
+	if _, err := os.Stat(l.path(id)); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", engine.ErrLeagueNotFound, id)
+	}
+
+	return l.openStore(id)
+}
+
+// Keys returns the API keys of league id, kept next to its database.
+func (l *Leagues) Keys(ctx context.Context, id string) (engine.KeyStore, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if engine.ValidateLeagueID(id) != nil {
+		return nil, fmt.Errorf("%w: %s", engine.ErrLeagueNotFound, id)
+	}
+
+	if _, err := os.Stat(l.path(id)); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", engine.ErrLeagueNotFound, id)
+	}
+
+	return OpenKeys(KeysPath(l.kind, l.path(id))), nil
+}
+
+// Players returns the player registry of league id, kept next to its
+// database.
+func (l *Leagues) Players(ctx context.Context, id string) (engine.PlayerRegistry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if engine.ValidateLeagueID(id) != nil {
+		return nil, fmt.Errorf("%w: %s", engine.ErrLeagueNotFound, id)
+	}
+
+	if _, err := os.Stat(l.path(id)); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", engine.ErrLeagueNotFound, id)
+	}
+
+	return OpenPlayers(PlayersPath(l.kind, l.path(id))), nil
+}
+
+// Close closes the stores of every league that was used.
+func (l *Leagues) Close() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, closeStore := range l.closers {
+		closeStore()
+	}
+
+	l.closers = nil
+	l.open = map[string]engine.PlayerStore{}
+}
+
+func (l *Leagues) openStore(id string) (engine.PlayerStore, error) {
+	store, closeStore, err := Open(l.kind, l.path(id))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open league %s: %w", id, err)
+	}
+
+	l.open[id] = store
+	l.closers = append(l.closers, closeStore)
+	return store, nil
+}
+
+func (l *Leagues) path(id string) string {
+	return filepath.Join(l.dir, id+l.ext)
+}
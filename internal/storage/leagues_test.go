@@ -0,0 +1,78 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/storage"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestLeagues(t *testing.T) {
+	ctx := context.Background()
+
+	for _, kind := range []string{storage.KindFile, storage.KindEventLog, storage.KindSQLite} {
+		t.Run(kind, func(t *testing.T) {
+			dir := t.TempDir()
+			leagues := mustOpenLeagues(t, kind, dir)
+
+			tests.AssertNoError(t, leagues.CreateLeague(ctx, "tuesday"))
+			tests.AssertNoError(t, leagues.CreateLeague(ctx, "friday"))
+
+			if err := leagues.CreateLeague(ctx, "friday"); !errors.Is(err, engine.ErrLeagueExists) {
+				t.Errorf("got error %v creating friday twice, want %v", err, engine.ErrLeagueExists)
+			}
+
+			if err := leagues.CreateLeague(ctx, "../friday"); !errors.Is(err, engine.ErrBadLeagueID) {
+				t.Errorf("got error %v creating ../friday, want %v", err, engine.ErrBadLeagueID)
+			}
+
+			tuesday := mustGetLeagueStore(t, leagues, "tuesday")
+			tests.AssertRecordWin(t, tuesday, "Pepper")
+
+			friday := mustGetLeagueStore(t, leagues, "friday")
+			tests.AssertPlayerScore(t, friday, "Pepper", 0)
+
+			if _, err := leagues.Store(ctx, "sunday"); !errors.Is(err, engine.ErrLeagueNotFound) {
+				t.Errorf("got error %v for sunday, want %v", err, engine.ErrLeagueNotFound)
+			}
+
+			leagues.Close()
+			reopened := mustOpenLeagues(t, kind, dir)
+
+			ids, err := reopened.ListLeagues(ctx)
+			tests.AssertNoError(t, err)
+			if !slices.Equal(ids, []string{"friday", "tuesday"}) {
+				t.Errorf("got leagues %v, want [friday tuesday]", ids)
+			}
+
+			tests.AssertPlayerScore(t, mustGetLeagueStore(t, reopened, "tuesday"), "Pepper", 1)
+		})
+	}
+}
+
+func mustOpenLeagues(t *testing.T, kind, dir string) *storage.Leagues {
+	t.Helper()
+
+	leagues, err := storage.NewLeagues(kind, dir)
+	if err != nil {
+		t.Fatalf("couldn't open the leagues in %s: %v", dir, err)
+	}
+	t.Cleanup(leagues.Close)
+
+	return leagues
+}
+
+func mustGetLeagueStore(t *testing.T, leagues *storage.Leagues, id string) engine.PlayerStore {
+	t.Helper()
+
+	store, err := leagues.Store(context.Background(), id)
+	if err != nil {
+		t.Fatalf("couldn't get the store of %s: %v", id, err)
+	}
+
+	return store
+}
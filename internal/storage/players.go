@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// playersExt names the file keeping the player registry of a league, next to
+// its database.
+const playersExt = ".players.json"
+
+// Players keeps the player registry of a league in a JSON file of its own,
+// whatever the kind of its store.
+type Players struct {
+	path string
+	lock sync.Mutex
+}
+
+// OpenPlayers keeps the registry in the file at path, created with the first
+// player.
+func OpenPlayers(path string) *Players {
+	return &Players{path: path}
+}
+
+// PlayersPath is where the player registry of the store of the given kind at
+// dbPath is kept.
+func PlayersPath(kind, dbPath string) string {
+	return strings.TrimSuffix(dbPath, extensions[kind]) + playersExt
+}
+
+func (p *Players) Register(ctx context.Context, name string, at time.Time) (engine.RegisteredPlayer, error) {
+	return p.change(ctx, func(registry engine.Registry) (engine.Registry, engine.RegisteredPlayer, error) {
+		return registry.Register(name, at)
+	})
+}
+
+func (p *Players) FindPlayer(ctx context.Context, name string) (engine.RegisteredPlayer, error) {
+	registry, err := p.ListPlayers(ctx)
+	if err != nil {
+		return engine.RegisteredPlayer{}, err
+	}
+
+	player := engine.Registry(registry).Find(name)
+	if player == nil {
+		return engine.RegisteredPlayer{}, fmt.Errorf("%w: %s", engine.ErrPlayerNotFound, name)
+	}
+
+	return *player, nil
+}
+
+func (p *Players) ListPlayers(ctx context.Context) ([]engine.RegisteredPlayer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.read()
+}
+
+func (p *Players) AddAlias(ctx context.Context, name, alias string) (engine.RegisteredPlayer, error) {
+	return p.change(ctx, func(registry engine.Registry) (engine.Registry, engine.RegisteredPlayer, error) {
+		return registry.AddAlias(name, alias)
+	})
+}
+
+func (p *Players) MergePlayers(ctx context.Context, from, into string, at time.Time) (engine.RegisteredPlayer, error) {
+	return p.change(ctx, func(registry engine.Registry) (engine.Registry, engine.RegisteredPlayer, error) {
+		return registry.Merge(from, into, at)
+	})
+}
+
+// change saves the registry as changed by change, unless it's left as it was.
+func (p *Players) change(ctx context.Context, change func(engine.Registry) (engine.Registry, engine.RegisteredPlayer, error)) (engine.RegisteredPlayer, error) {
+	if err := ctx.Err(); err != nil {
+		return engine.RegisteredPlayer{}, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	registry, err := p.read()
+	if err != nil {
+		return engine.RegisteredPlayer{}, err
+	}
+
+	changed, player, err := change(registry)
+	if err != nil {
+		return engine.RegisteredPlayer{}, err
+	}
+
+	if reflect.DeepEqual(changed, registry) {
+		return player, nil
+	}
+
+	return player, writeJSON(p.path, changed)
+}
+
+func (p *Players) read() (engine.Registry, error) {
+	var registry engine.Registry
+	if err := readJSON(p.path, &registry); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,87 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/storage"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestPlayers(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2026, time.May, 9, 21, 0, 0, 0, time.UTC)
+	path := storage.PlayersPath(storage.KindSQLite, filepath.Join(t.TempDir(), "game.db"))
+
+	t.Run("it keeps players and their aliases across openings", func(t *testing.T) {
+		_, err := storage.OpenPlayers(path).Register(ctx, " Cleo ", at)
+		tests.AssertNoError(t, err)
+		_, err = storage.OpenPlayers(path).AddAlias(ctx, "cleo", "Cleopatra")
+		tests.AssertNoError(t, err)
+
+		got, err := storage.OpenPlayers(path).FindPlayer(ctx, "CLEOPATRA")
+		tests.AssertNoError(t, err)
+		if got.Name != "Cleo" || got.ID != "cleo" {
+			t.Errorf("got player %+v, want Cleo", got)
+		}
+	})
+
+	t.Run("it doesn't find players nobody registered", func(t *testing.T) {
+		if _, err := storage.OpenPlayers(path).FindPlayer(ctx, "Chris"); !errors.Is(err, engine.ErrPlayerNotFound) {
+			t.Errorf("got error %v, want %v", err, engine.ErrPlayerNotFound)
+		}
+	})
+
+	t.Run("it keeps the players of every league apart", func(t *testing.T) {
+		leagues := mustOpenLeagues(t, storage.KindFile, t.TempDir())
+		tests.AssertNoError(t, leagues.CreateLeague(ctx, "tuesday"))
+		tests.AssertNoError(t, leagues.CreateLeague(ctx, "friday"))
+
+		tuesday, err := leagues.Players(ctx, "tuesday")
+		tests.AssertNoError(t, err)
+		_, err = tuesday.Register(ctx, "Cleo", at)
+		tests.AssertNoError(t, err)
+
+		friday, err := leagues.Players(ctx, "friday")
+		tests.AssertNoError(t, err)
+		if _, err := friday.FindPlayer(ctx, "Cleo"); !errors.Is(err, engine.ErrPlayerNotFound) {
+			t.Errorf("got error %v for a player of tuesday, want %v", err, engine.ErrPlayerNotFound)
+		}
+	})
+}
+
+func TestMergePlayers(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2026, time.May, 9, 21, 0, 0, 0, time.UTC)
+
+	for name, open := range openers() {
+		t.Run(name+" merges the matches and wins of duplicate players", func(t *testing.T) {
+			store := open(t)
+			players := storage.OpenPlayers(filepath.Join(t.TempDir(), "league.players.json"))
+
+			for _, match := range []engine.Match{
+				{ID: "1", Game: "holdem", StartedAt: at, FinishedAt: at, Participants: []string{"Cleo", "cleo"}, Winner: "cleo"},
+				{ID: "2", Game: "holdem", StartedAt: at, FinishedAt: at, Winner: "Cleo"},
+			} {
+				tests.AssertNoError(t, store.RecordMatch(ctx, match))
+			}
+
+			player, err := engine.MergePlayers(ctx, players, store, "cleo", "Cleo", "admin", at)
+			tests.AssertNoError(t, err)
+			if player.Name != "Cleo" {
+				t.Errorf("got player %+v, want Cleo", player)
+			}
+
+			tests.AssertPlayerScore(t, store, "Cleo", 2)
+			tests.AssertPlayerScore(t, store, "cleo", 0)
+			tests.AssertMatches(t, store, []engine.Match{
+				{ID: "1", Game: "holdem", StartedAt: at, FinishedAt: at, Participants: []string{"Cleo"}, Winner: "Cleo"},
+				{ID: "2", Game: "holdem", StartedAt: at, FinishedAt: at, Winner: "Cleo"},
+			})
+		})
+	}
+}
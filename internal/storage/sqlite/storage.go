@@ -0,0 +1,614 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+
+	// registers the pure Go "sqlite" driver
+	_ "modernc.org/sqlite"
+)
+
+// timeLayout stores times with a fixed number of digits, so they sort
+// chronologically as text.
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// busyTimeout is how long, in milliseconds, a connection waits for another
+// process holding the database lock before giving up.
+const busyTimeout = 5000
+
+// migrations bring the schema up to date. The database records how many have
+// been applied in PRAGMA user_version, so new ones are only ever appended.
+var migrations = []string{
+	`CREATE TABLE players (
+		name TEXT PRIMARY KEY,
+		wins INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX players_by_wins ON players (wins DESC, name);`,
+	// players.wins is kept as a tally of the matches each player won, so
+	// the league can still be read straight from the index
+	`CREATE TABLE matches (
+		id TEXT PRIMARY KEY,
+		game TEXT NOT NULL,
+		started_at TEXT NOT NULL,
+		finished_at TEXT NOT NULL,
+		entrants INTEGER NOT NULL DEFAULT 0,
+		participants TEXT NOT NULL DEFAULT '[]',
+		finishing_order TEXT NOT NULL DEFAULT '[]',
+		winner TEXT NOT NULL
+	);
+	CREATE INDEX matches_by_winner ON matches (winner);`,
+	// closing a season archives the league here and empties players
+	`CREATE TABLE seasons (
+		id TEXT PRIMARY KEY,
+		started_at TEXT NOT NULL,
+		ended_at TEXT NOT NULL,
+		standings TEXT NOT NULL
+	);`,
+	// every correction is recorded here, along with what it changed, and
+	// the log can only ever be appended to
+	`CREATE TABLE audit (
+		seq INTEGER PRIMARY KEY,
+		action TEXT NOT NULL,
+		corrected_by TEXT NOT NULL,
+		corrected_at TEXT NOT NULL,
+		entry TEXT NOT NULL
+	);
+	CREATE TRIGGER audit_no_update BEFORE UPDATE ON audit
+	BEGIN
+		SELECT RAISE(ABORT, 'the audit log can only be appended to');
+	END;
+	CREATE TRIGGER audit_no_delete BEFORE DELETE ON audit
+	BEGIN
+		SELECT RAISE(ABORT, 'the audit log can only be appended to');
+	END;`,
+}
+
+type PlayerStore struct {
+	db      *sql.DB
+	changes engine.Changes
+}
+
+func PlayerStoreFromFile(path string) (*PlayerStore, func(), error) {
+	db, err := Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	closeFunc := func() {
+		if err := db.Close(); err != nil {
+			log.Printf("couldn't close the %s: %v", path, err)
+		}
+	}
+
+	store, err := NewPlayerStore(db)
+	if err != nil {
+		closeFunc()
+		return nil, nil, fmt.Errorf("problem creating sqlite store, %v", err)
+	}
+
+	return store, closeFunc, nil
+}
+
+// Open opens the database at path in WAL mode so the web server and the CLI
+// can use it at the same time.
+func Open(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)", path, busyTimeout)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("problem opening %s %v", path, err)
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("problem opening %s %v", path, err)
+	}
+
+	return db, nil
+}
+
+func NewPlayerStore(db *sql.DB) (*PlayerStore, error) {
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
+	return &PlayerStore{db: db}, nil
+}
+
+func migrate(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("couldn't begin migration: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var version int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("couldn't read schema version: %w", err)
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this build supports (%d)", version, len(migrations))
+	}
+
+	for i, migration := range migrations[version:] {
+		if _, err := tx.Exec(migration); err != nil {
+			return fmt.Errorf("couldn't apply migration %d: %w", version+i+1, err)
+		}
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(migrations))); err != nil {
+		return fmt.Errorf("couldn't record schema version: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (s *PlayerStore) GetLeague(ctx context.Context) (engine.League, error) {
+	return getLeague(ctx, s.db)
+}
+
+func getLeague(ctx context.Context, db querier) (engine.League, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, wins FROM players ORDER BY wins DESC, name")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't query the league: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("couldn't close the league rows: %v", err)
+		}
+	}()
+
+	league := engine.League{}
+	for rows.Next() {
+		var player engine.Player
+		if err := rows.Scan(&player.Name, &player.Wins); err != nil {
+			return nil, fmt.Errorf("couldn't scan a player: %w", err)
+		}
+		league = append(league, player)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read the league: %w", err)
+	}
+
+	return league, nil
+}
+
+func (s *PlayerStore) GetPlayerScore(ctx context.Context, name string) (int, error) {
+	var wins int
+
+	err := s.db.QueryRowContext(ctx, "SELECT wins FROM players WHERE name = ?", name).Scan(&wins)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("couldn't query the score of %s: %w", name, err)
+	}
+
+	return wins, nil
+}
+
+func (s *PlayerStore) GetMatches(ctx context.Context) ([]engine.Match, error) {
+	return getMatches(ctx, s.db)
+}
+
+func getMatches(ctx context.Context, db querier) ([]engine.Match, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, game, started_at, finished_at, entrants,
+		participants, finishing_order, winner
+		FROM matches ORDER BY rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't query the matches: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("couldn't close the match rows: %v", err)
+		}
+	}()
+
+	var matches []engine.Match
+	for rows.Next() {
+		match, err := scanMatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read the matches: %w", err)
+	}
+
+	return matches, nil
+}
+
+func (s *PlayerStore) RecordWin(ctx context.Context, name string) error {
+	return s.RecordMatch(ctx, engine.ManualWin(name, time.Now().UTC()))
+}
+
+func (s *PlayerStore) RecordMatch(ctx context.Context, match engine.Match) error {
+	participants, err := json.Marshal(match.Participants)
+	if err != nil {
+		return err
+	}
+	finishingOrder, err := json.Marshal(match.FinishingOrder)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't begin recording match %s: %w", match.ID, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO matches (id, game, started_at, finished_at, entrants,
+		participants, finishing_order, winner)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		match.ID,
+		match.Game,
+		match.StartedAt.UTC().Format(timeLayout),
+		match.FinishedAt.UTC().Format(timeLayout),
+		match.Entrants,
+		string(participants),
+		string(finishingOrder),
+		match.Winner,
+	)
+	if err != nil {
+		return fmt.Errorf("couldn't record match %s: %w", match.ID, err)
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO players (name, wins) VALUES (?, 1)
+		ON CONFLICT (name) DO UPDATE SET wins = wins + 1`,
+		match.Winner,
+	)
+	if err != nil {
+		return fmt.Errorf("couldn't record a win for %s: %w", match.Winner, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.changes.Notify()
+	return nil
+}
+
+func (s *PlayerStore) GetSeasons(ctx context.Context) ([]engine.Season, error) {
+	return getSeasons(ctx, s.db)
+}
+
+func (s *PlayerStore) CloseSeason(ctx context.Context, id string, at time.Time) (engine.Season, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return engine.Season{}, fmt.Errorf("couldn't begin closing season %s: %w", id, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	closed, err := getSeasons(ctx, tx)
+	if err != nil {
+		return engine.Season{}, err
+	}
+
+	league, err := getLeague(ctx, tx)
+	if err != nil {
+		return engine.Season{}, err
+	}
+
+	var firstPlayed sql.NullString
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT MIN(started_at) FROM matches WHERE started_at > ?",
+		time.Time{}.Format(timeLayout),
+	).Scan(&firstPlayed)
+	if err != nil {
+		return engine.Season{}, fmt.Errorf("couldn't query the first match: %w", err)
+	}
+
+	var first time.Time
+	if firstPlayed.Valid {
+		if first, err = time.Parse(time.RFC3339Nano, firstPlayed.String); err != nil {
+			return engine.Season{}, fmt.Errorf("bad start time of the first match: %w", err)
+		}
+	}
+
+	season, err := engine.CloseSeason(id, closed, first, league, at)
+	if err != nil {
+		return engine.Season{}, err
+	}
+
+	standings, err := json.Marshal(season.Standings)
+	if err != nil {
+		return engine.Season{}, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO seasons (id, started_at, ended_at, standings) VALUES (?, ?, ?, ?)",
+		season.ID,
+		season.StartedAt.UTC().Format(timeLayout),
+		season.EndedAt.UTC().Format(timeLayout),
+		string(standings),
+	)
+	if err != nil {
+		return engine.Season{}, fmt.Errorf("couldn't archive season %s: %w", id, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM players"); err != nil {
+		return engine.Season{}, fmt.Errorf("couldn't start the league over: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return engine.Season{}, err
+	}
+
+	s.changes.Notify()
+	return season, nil
+}
+
+func (s *PlayerStore) Correct(ctx context.Context, correction engine.Correction, by string, at time.Time) (engine.AuditEntry, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return engine.AuditEntry{}, fmt.Errorf("couldn't begin correcting: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	matches, err := getMatches(ctx, tx)
+	if err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	league, err := getLeague(ctx, tx)
+	if err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	closed, err := getSeasons(ctx, tx)
+	if err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	var corrections int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit").Scan(&corrections); err != nil {
+		return engine.AuditEntry{}, fmt.Errorf("couldn't count the corrections: %w", err)
+	}
+
+	entry, err := engine.Correct(correction, matches, league, closed, by, at, corrections+1)
+	if err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	if err := applyCorrection(ctx, tx, entry, matches); err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	s.changes.Notify()
+	return entry, nil
+}
+
+// applyCorrection changes the matches and the wins the correction of entry
+// is about, and appends entry to the audit log.
+func applyCorrection(ctx context.Context, tx *sql.Tx, entry engine.AuditEntry, matches []engine.Match) error {
+	corrected, _ := entry.Apply(matches, nil)
+
+	for _, match := range matches {
+		i := slices.IndexFunc(corrected, func(m engine.Match) bool { return m.ID == match.ID })
+
+		switch {
+		case i < 0:
+			if _, err := tx.ExecContext(ctx, "DELETE FROM matches WHERE id = ?", match.ID); err != nil {
+				return fmt.Errorf("couldn't void match %s: %w", match.ID, err)
+			}
+		case !reflect.DeepEqual(corrected[i], match):
+			if err := updateMatch(ctx, tx, corrected[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, wins := range entry.After.Wins {
+		var err error
+		if wins > 0 {
+			_, err = tx.ExecContext(
+				ctx,
+				`INSERT INTO players (name, wins) VALUES (?, ?)
+				ON CONFLICT (name) DO UPDATE SET wins = excluded.wins`,
+				name,
+				wins,
+			)
+		} else {
+			_, err = tx.ExecContext(ctx, "DELETE FROM players WHERE name = ?", name)
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't correct the wins of %s: %w", name, err)
+		}
+	}
+
+	recorded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO audit (seq, action, corrected_by, corrected_at, entry) VALUES (?, ?, ?, ?, ?)",
+		entry.Seq,
+		entry.Action,
+		entry.By,
+		entry.At.UTC().Format(timeLayout),
+		string(recorded),
+	)
+	if err != nil {
+		return fmt.Errorf("couldn't append to the audit log: %w", err)
+	}
+
+	return nil
+}
+
+// updateMatch changes who played match and how they finished.
+func updateMatch(ctx context.Context, tx *sql.Tx, match engine.Match) error {
+	participants, err := json.Marshal(match.Participants)
+	if err != nil {
+		return err
+	}
+	finishingOrder, err := json.Marshal(match.FinishingOrder)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE matches SET participants = ?, finishing_order = ?, winner = ? WHERE id = ?",
+		string(participants),
+		string(finishingOrder),
+		match.Winner,
+		match.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("couldn't correct match %s: %w", match.ID, err)
+	}
+
+	return nil
+}
+
+func (s *PlayerStore) GetAudit(ctx context.Context) ([]engine.AuditEntry, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT entry FROM audit ORDER BY seq")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't query the audit log: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("couldn't close the audit rows: %v", err)
+		}
+	}()
+
+	var audit []engine.AuditEntry
+	for rows.Next() {
+		var recorded string
+		if err := rows.Scan(&recorded); err != nil {
+			return nil, fmt.Errorf("couldn't scan an audit entry: %w", err)
+		}
+
+		var entry engine.AuditEntry
+		if err := json.Unmarshal([]byte(recorded), &entry); err != nil {
+			return nil, fmt.Errorf("bad audit entry: %w", err)
+		}
+		audit = append(audit, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read the audit log: %w", err)
+	}
+
+	return audit, nil
+}
+
+// Subscribe tells when this store records a match, closes a season or
+// corrects a result. What other processes sharing the database change goes
+// unnoticed.
+func (s *PlayerStore) Subscribe() (<-chan struct{}, func()) {
+	return s.changes.Subscribe()
+}
+
+// querier is satisfied by both the database and its transactions.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func getSeasons(ctx context.Context, db querier) ([]engine.Season, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id, started_at, ended_at, standings FROM seasons ORDER BY rowid")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't query the seasons: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("couldn't close the season rows: %v", err)
+		}
+	}()
+
+	var seasons []engine.Season
+	for rows.Next() {
+		var season engine.Season
+		var startedAt, endedAt, standings string
+
+		if err := rows.Scan(&season.ID, &startedAt, &endedAt, &standings); err != nil {
+			return nil, fmt.Errorf("couldn't scan a season: %w", err)
+		}
+		if season.StartedAt, err = time.Parse(time.RFC3339Nano, startedAt); err != nil {
+			return nil, fmt.Errorf("bad start of season %s: %w", season.ID, err)
+		}
+		if season.EndedAt, err = time.Parse(time.RFC3339Nano, endedAt); err != nil {
+			return nil, fmt.Errorf("bad end of season %s: %w", season.ID, err)
+		}
+		if err := json.Unmarshal([]byte(standings), &season.Standings); err != nil {
+			return nil, fmt.Errorf("bad standings of season %s: %w", season.ID, err)
+		}
+
+		seasons = append(seasons, season)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read the seasons: %w", err)
+	}
+
+	return seasons, nil
+}
+
+func scanMatch(rows *sql.Rows) (engine.Match, error) {
+	var match engine.Match
+	var startedAt, finishedAt, participants, finishingOrder string
+
+	err := rows.Scan(
+		&match.ID,
+		&match.Game,
+		&startedAt,
+		&finishedAt,
+		&match.Entrants,
+		&participants,
+		&finishingOrder,
+		&match.Winner,
+	)
+	if err != nil {
+		return engine.Match{}, fmt.Errorf("couldn't scan a match: %w", err)
+	}
+
+	if match.StartedAt, err = time.Parse(time.RFC3339Nano, startedAt); err != nil {
+		return engine.Match{}, fmt.Errorf("bad start time of match %s: %w", match.ID, err)
+	}
+	if match.FinishedAt, err = time.Parse(time.RFC3339Nano, finishedAt); err != nil {
+		return engine.Match{}, fmt.Errorf("bad finish time of match %s: %w", match.ID, err)
+	}
+	if err := json.Unmarshal([]byte(participants), &match.Participants); err != nil {
+		return engine.Match{}, fmt.Errorf("bad participants of match %s: %w", match.ID, err)
+	}
+	if err := json.Unmarshal([]byte(finishingOrder), &match.FinishingOrder); err != nil {
+		return engine.Match{}, fmt.Errorf("bad finishing order of match %s: %w", match.ID, err)
+	}
+
+	return match, nil
+}
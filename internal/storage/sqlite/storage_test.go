@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestSQLiteStore(t *testing.T) {
+	t.Run("league is ordered by wins", func(t *testing.T) {
+		store := mustMakeStore(t, filepath.Join(t.TempDir(), "game.db"))
+
+		tests.AssertRecordWin(t, store, "Cleo")
+		tests.AssertRecordWin(t, store, "Chris")
+		tests.AssertRecordWin(t, store, "Chris")
+
+		want := engine.League{
+			{Name: "Chris", Wins: 2},
+			{Name: "Cleo", Wins: 1},
+		}
+
+		tests.AssertStoreLeague(t, store, want)
+	})
+
+	t.Run("get player score", func(t *testing.T) {
+		store := mustMakeStore(t, filepath.Join(t.TempDir(), "game.db"))
+
+		tests.AssertRecordWin(t, store, "Chris")
+
+		tests.AssertPlayerScore(t, store, "Chris", 1)
+		tests.AssertPlayerScore(t, store, "Apollo", 0)
+	})
+
+	t.Run("two stores on the same file see each other's wins", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "game.db")
+		webserver := mustMakeStore(t, path)
+		cli := mustMakeStore(t, path)
+
+		var wg sync.WaitGroup
+		for _, store := range []*PlayerStore{webserver, cli} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					tests.AssertRecordWin(t, store, "Pepper")
+				}
+			}()
+		}
+		wg.Wait()
+
+		tests.AssertPlayerScore(t, webserver, "Pepper", 100)
+	})
+
+	t.Run("records matches", func(t *testing.T) {
+		store := mustMakeStore(t, filepath.Join(t.TempDir(), "game.db"))
+
+		match := engine.Match{
+			ID:             "1",
+			Game:           "texas-holdem",
+			StartedAt:      time.Date(2026, 7, 1, 19, 0, 0, 0, time.UTC),
+			FinishedAt:     time.Date(2026, 7, 1, 21, 0, 0, 0, time.UTC),
+			Entrants:       5,
+			FinishingOrder: []string{"Cleo"},
+			Winner:         "Cleo",
+		}
+		tests.AssertNoError(t, store.RecordMatch(context.Background(), match))
+
+		tests.AssertMatches(t, store, []engine.Match{match})
+		tests.AssertPlayerScore(t, store, "Cleo", 1)
+	})
+
+	t.Run("closing a season archives the league and starts it over", func(t *testing.T) {
+		store := mustMakeStore(t, filepath.Join(t.TempDir(), "game.db"))
+		tests.AssertRecordWin(t, store, "Cleo")
+
+		at := time.Now().UTC()
+		season, err := store.CloseSeason(context.Background(), "2026-Q3", at)
+		tests.AssertNoError(t, err)
+
+		tests.AssertStoreLeague(t, store, engine.League{})
+		tests.AssertLeague(t, season.Standings, engine.League{{Name: "Cleo", Wins: 1}})
+
+		seasons, err := store.GetSeasons(context.Background())
+		tests.AssertNoError(t, err)
+		if len(seasons) != 1 || !seasons[0].EndedAt.Equal(at) {
+			t.Errorf("got seasons %+v, want one ending at %v", seasons, at)
+		}
+	})
+
+	t.Run("the audit log can only be appended to", func(t *testing.T) {
+		store := mustMakeStore(t, filepath.Join(t.TempDir(), "game.db"))
+		tests.AssertRecordWin(t, store, "Chris")
+
+		_, err := store.Correct(context.Background(), engine.Correction{Action: engine.ActionAdjust, Player: "Chris", Wins: 1}, "admin", time.Now().UTC())
+		tests.AssertNoError(t, err)
+		tests.AssertPlayerScore(t, store, "Chris", 2)
+
+		if _, err := store.db.Exec("UPDATE audit SET corrected_by = 'someone else'"); err == nil {
+			t.Error("changed an audit entry")
+		}
+		if _, err := store.db.Exec("DELETE FROM audit"); err == nil {
+			t.Error("deleted an audit entry")
+		}
+	})
+
+	t.Run("migrations are only applied once", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "game.db")
+		store := mustMakeStore(t, path)
+		tests.AssertRecordWin(t, store, "Chris")
+
+		reopened := mustMakeStore(t, path)
+
+		tests.AssertPlayerScore(t, reopened, "Chris", 1)
+	})
+}
+
+func mustMakeStore(t *testing.T, path string) *PlayerStore {
+	t.Helper()
+
+	store, closeStore, err := PlayerStoreFromFile(path)
+	if err != nil {
+		t.Fatalf("couldn't open the sqlite store: %v", err)
+	}
+	t.Cleanup(closeStore)
+
+	return store
+}
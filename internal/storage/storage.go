@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/storage/eventlog"
+	"github.com/oblassov/game-score-server/internal/storage/filesystem"
+	"github.com/oblassov/game-score-server/internal/storage/sqlite"
+)
+
+const (
+	KindFile     = "file"
+	KindEventLog = "eventlog"
+	KindSQLite   = "sqlite"
+)
+
+// defaultName is the name of the database every kind of store is kept under
+// unless told otherwise.
+const defaultName = "./game"
+
+// DefaultPath is where the database of the given kind is kept unless told
+// otherwise, so stores of different kinds never share a file.
+func DefaultPath(kind string) string {
+	return defaultName + extensions[kind]
+}
+
+// Open opens the PlayerStore of the given kind at path, returning a function
+// that closes it.
+func Open(kind, path string) (engine.PlayerStore, func(), error) {
+	switch kind {
+	case KindFile:
+		return opened(filesystem.PlayerStoreFromFile(path))
+	case KindEventLog:
+		return opened(eventlog.PlayerStoreFromFile(path))
+	case KindSQLite:
+		return opened(sqlite.PlayerStoreFromFile(path))
+	default:
+		return nil, nil, fmt.Errorf("unknown store %q, want one of %s, %s or %s", kind, KindFile, KindEventLog, KindSQLite)
+	}
+}
+
+// opened hides the concrete store type, making sure a failed open doesn't
+// hand out a non-nil interface wrapping a nil store.
+func opened[S engine.PlayerStore](store S, closeStore func(), err error) (engine.PlayerStore, func(), error) {
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return store, closeStore, nil
+}
@@ -0,0 +1,126 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/storage"
+	"github.com/oblassov/game-score-server/internal/storage/inmemory"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestSubscribe(t *testing.T) {
+	ctx := context.Background()
+
+	for name, open := range openers() {
+		t.Run(name+" tells subscribers about wins and seasons", func(t *testing.T) {
+			store := open(t)
+			notifier, ok := store.(engine.ChangeNotifier)
+			if !ok {
+				t.Fatalf("%T doesn't tell about changes", store)
+			}
+
+			changes, cancel := notifier.Subscribe()
+			defer cancel()
+
+			tests.AssertRecordWin(t, store, "Pepper")
+			assertChanged(t, changes)
+
+			_, err := store.CloseSeason(ctx, "2026", time.Now())
+			tests.AssertNoError(t, err)
+			assertChanged(t, changes)
+		})
+	}
+}
+
+func TestLeagueOrder(t *testing.T) {
+	for name, open := range openers() {
+		t.Run(name+" orders players with as many wins by name", func(t *testing.T) {
+			store := open(t)
+
+			for _, winner := range []string{"Ruth", "Cleo", "Chris", "Cleo"} {
+				tests.AssertRecordWin(t, store, winner)
+			}
+
+			tests.AssertStoreLeague(t, store, engine.League{
+				{Name: "Cleo", Wins: 2},
+				{Name: "Chris", Wins: 1},
+				{Name: "Ruth", Wins: 1},
+			})
+		})
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	ctx := context.Background()
+	at := time.Date(2026, time.May, 2, 21, 0, 0, 0, time.UTC)
+
+	for name, open := range openers() {
+		t.Run(name+" corrects results and audits them", func(t *testing.T) {
+			store := open(t)
+			corrector, ok := store.(engine.Corrector)
+			if !ok {
+				t.Fatalf("%T can't correct results", store)
+			}
+
+			for _, match := range []engine.Match{
+				{ID: "1", Game: "holdem", StartedAt: at, FinishedAt: at, Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Cleo", "Chris"}, Winner: "Cleo"},
+				{ID: "2", Game: "holdem", StartedAt: at, FinishedAt: at, Winner: "Chris"},
+			} {
+				tests.AssertNoError(t, store.RecordMatch(ctx, match))
+			}
+
+			_, err := corrector.Correct(ctx, engine.Correction{Action: engine.ActionReassign, Match: "1", Winner: "Chris"}, "admin", at)
+			tests.AssertNoError(t, err)
+			_, err = corrector.Correct(ctx, engine.Correction{Action: engine.ActionVoid, Match: "2"}, "admin", at)
+			tests.AssertNoError(t, err)
+			_, err = corrector.Correct(ctx, engine.Correction{Action: engine.ActionAdjust, Player: "Cleo", Wins: 2, Reason: "lost the paper"}, "admin", at)
+			tests.AssertNoError(t, err)
+
+			tests.AssertPlayerScore(t, store, "Chris", 1)
+			tests.AssertPlayerScore(t, store, "Cleo", 2)
+			tests.AssertMatches(t, store, []engine.Match{
+				{ID: "1", Game: "holdem", StartedAt: at, FinishedAt: at, Participants: []string{"Cleo", "Chris"}, FinishingOrder: []string{"Chris", "Cleo"}, Winner: "Chris"},
+			})
+
+			audit, err := corrector.GetAudit(ctx)
+			tests.AssertNoError(t, err)
+			if len(audit) != 3 {
+				t.Fatalf("got %d audit entries, want 3", len(audit))
+			}
+			if got := audit[1]; got.Seq != 2 || got.Action != engine.ActionVoid || got.By != "admin" || got.Before.Wins["Chris"] != 2 || got.After.Wins["Chris"] != 1 {
+				t.Errorf("got audit entry %+v, want match 2 voided by admin taking Chris from 2 wins to 1", got)
+			}
+		})
+	}
+}
+
+// openers open an empty store of every kind.
+func openers() map[string]func(t *testing.T) engine.PlayerStore {
+	stores := map[string]func(t *testing.T) engine.PlayerStore{
+		"inmemory": func(*testing.T) engine.PlayerStore { return inmemory.NewInMemoryPlayerStore() },
+	}
+	for _, kind := range []string{storage.KindFile, storage.KindEventLog, storage.KindSQLite} {
+		stores[kind] = func(t *testing.T) engine.PlayerStore {
+			store, closeStore, err := storage.Open(kind, filepath.Join(t.TempDir(), "league"))
+			tests.AssertNoError(t, err)
+			t.Cleanup(closeStore)
+			return store
+		}
+	}
+
+	return stores
+}
+
+func assertChanged(t testing.TB, changes <-chan struct{}) {
+	t.Helper()
+
+	select {
+	case <-changes:
+	default:
+		t.Error("didn't get told about the change")
+	}
+}
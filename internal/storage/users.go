@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// usersExt names the file keeping the users of the server, next to its
+// database.
+const usersExt = ".users.json"
+
+// Users keeps the users of the server in a JSON file of their own, whatever
+// the kind of its store.
+type Users struct {
+	path string
+	lock sync.Mutex
+}
+
+// OpenUsers keeps users in the file at path, created with the first user.
+func OpenUsers(path string) *Users {
+	return &Users{path: path}
+}
+
+// UsersPath is where the users of the server with the store of the given
+// kind at dbPath are kept.
+func UsersPath(kind, dbPath string) string {
+	return strings.TrimSuffix(dbPath, extensions[kind]) + usersExt
+}
+
+func (u *Users) AddUser(ctx context.Context, user engine.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	users, err := u.read()
+	if err != nil {
+		return err
+	}
+
+	if slices.ContainsFunc(users, func(other engine.User) bool { return other.Name == user.Name }) {
+		return fmt.Errorf("%w: %s", engine.ErrUserExists, user.Name)
+	}
+
+	return writeJSON(u.path, append(users, user))
+}
+
+func (u *Users) GetUser(ctx context.Context, name string) (engine.User, error) {
+	if err := ctx.Err(); err != nil {
+		return engine.User{}, err
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	users, err := u.read()
+	if err != nil {
+		return engine.User{}, err
+	}
+
+	i := slices.IndexFunc(users, func(user engine.User) bool { return user.Name == name })
+	if i == -1 {
+		return engine.User{}, fmt.Errorf("%w: %s", engine.ErrUserNotFound, name)
+	}
+
+	return users[i], nil
+}
+
+func (u *Users) ListUsers(ctx context.Context) ([]engine.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	return u.read()
+}
+
+func (u *Users) RemoveUser(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	users, err := u.read()
+	if err != nil {
+		return err
+	}
+
+	kept := slices.DeleteFunc(users, func(user engine.User) bool { return user.Name == name })
+	if len(kept) == len(users) {
+		return fmt.Errorf("%w: %s", engine.ErrUserNotFound, name)
+	}
+
+	return writeJSON(u.path, kept)
+}
+
+func (u *Users) read() ([]engine.User, error) {
+	var users []engine.User
+	if err := readJSON(u.path, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
@@ -0,0 +1,49 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+	"github.com/oblassov/game-score-server/internal/storage"
+	"github.com/oblassov/game-score-server/tests"
+)
+
+func TestUsers(t *testing.T) {
+	ctx := context.Background()
+	path := storage.UsersPath(storage.KindSQLite, filepath.Join(t.TempDir(), "game.db"))
+
+	user, err := engine.NewUser("Pepper", engine.RoleAdmin, "password", time.Now().UTC())
+	tests.AssertNoError(t, err)
+
+	t.Run("it keeps users across openings", func(t *testing.T) {
+		tests.AssertNoError(t, storage.OpenUsers(path).AddUser(ctx, user))
+
+		got, err := engine.Login(ctx, storage.OpenUsers(path), "Pepper", "password")
+		tests.AssertNoError(t, err)
+		if got.Role != engine.RoleAdmin {
+			t.Errorf("got user %+v, want %+v", got, user)
+		}
+	})
+
+	t.Run("it refuses to add a user twice", func(t *testing.T) {
+		if err := storage.OpenUsers(path).AddUser(ctx, user); !errors.Is(err, engine.ErrUserExists) {
+			t.Errorf("got error %v, want %v", err, engine.ErrUserExists)
+		}
+	})
+
+	t.Run("it removes users", func(t *testing.T) {
+		users := storage.OpenUsers(path)
+		tests.AssertNoError(t, users.RemoveUser(ctx, "Pepper"))
+
+		if _, err := users.GetUser(ctx, "Pepper"); !errors.Is(err, engine.ErrUserNotFound) {
+			t.Errorf("got error %v for a removed user, want %v", err, engine.ErrUserNotFound)
+		}
+		if err := users.RemoveUser(ctx, "Pepper"); !errors.Is(err, engine.ErrUserNotFound) {
+			t.Errorf("got error %v removing them twice, want %v", err, engine.ErrUserNotFound)
+		}
+	})
+}
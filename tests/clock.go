@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oblassov/game-score-server/internal/engine"
+)
+
+// ManualClock only moves when it's advanced, running the calls that come due
+// on the way in order and in the goroutine advancing it.
+type ManualClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+type manualTimer struct {
+	clock *ManualClock
+	at    time.Time
+	f     func()
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) engine.Timer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	timer := &manualTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// Advance moves the clock d forward, stopping at every call due on the way.
+// Calls scheduled by those that are due before the clock gets there run too.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	until := c.now.Add(d)
+	c.lock.Unlock()
+
+	for {
+		c.lock.Lock()
+		i := c.next(until)
+		if i < 0 {
+			c.now = until
+			c.lock.Unlock()
+			return
+		}
+
+		timer := c.timers[i]
+		c.timers = slices.Delete(c.timers, i, i+1)
+		c.now = timer.at
+		c.lock.Unlock()
+
+		timer.f()
+	}
+}
+
+// next finds the earliest call due by until, first scheduled first.
+func (c *ManualClock) next(until time.Time) int {
+	next := -1
+
+	for i, timer := range c.timers {
+		if timer.at.After(until) {
+			continue
+		}
+		if next < 0 || timer.at.Before(c.timers[next].at) {
+			next = i
+		}
+	}
+
+	return next
+}
+
+func (t *manualTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	i := slices.Index(t.clock.timers, t)
+	if i < 0 {
+		return false
+	}
+
+	t.clock.timers = slices.Delete(t.clock.timers, i, i+1)
+	return true
+}
@@ -2,12 +2,16 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,7 +29,7 @@ var (
 func CreateTempFile(t testing.TB, initialData string) (tmpfile *os.File, removeFile func()) {
 	t.Helper()
 
-	tmpfile, err := os.CreateTemp("", "db")
+	tmpfile, err := os.CreateTemp(t.TempDir(), "db")
 	if err != nil {
 		t.Fatalf("could not create temp file %v", err)
 	}
@@ -51,57 +55,510 @@ type StubPlayerStore struct {
 	Scores   map[string]int
 	WinCalls []string
 	League   engine.League
+	Matches  []engine.Match
+	Seasons  []engine.Season
+	Audit    []engine.AuditEntry
+	Err      error
+	// Changes are told about every win recorded.
+	engine.Changes
 }
 
-func (s *StubPlayerStore) GetPlayerScore(name string) int {
+func (s *StubPlayerStore) GetPlayerScore(_ context.Context, name string) (int, error) {
 	score := s.Scores[name]
-	return score
+	return score, s.Err
 }
 
-func (s *StubPlayerStore) RecordWin(name string) {
+func (s *StubPlayerStore) RecordWin(_ context.Context, name string) error {
+	if s.Err != nil {
+		return s.Err
+	}
+
 	s.WinCalls = append(s.WinCalls, name)
+	s.Notify()
+	return nil
+}
+
+// RecordMatch counts as a call to RecordWin for the winner, so games can be
+// asserted on with AssertPlayerWin.
+func (s *StubPlayerStore) RecordMatch(_ context.Context, match engine.Match) error {
+	if s.Err != nil {
+		return s.Err
+	}
+
+	s.Matches = append(s.Matches, match)
+	s.WinCalls = append(s.WinCalls, match.Winner)
+	s.Notify()
+	return nil
+}
+
+func (s *StubPlayerStore) GetMatches(_ context.Context) ([]engine.Match, error) {
+	return s.Matches, s.Err
+}
+
+func (s *StubPlayerStore) GetLeague(_ context.Context) (engine.League, error) {
+	return s.League, s.Err
+}
+
+func (s *StubPlayerStore) GetSeasons(_ context.Context) ([]engine.Season, error) {
+	return s.Seasons, s.Err
+}
+
+func (s *StubPlayerStore) CloseSeason(_ context.Context, id string, at time.Time) (engine.Season, error) {
+	if s.Err != nil {
+		return engine.Season{}, s.Err
+	}
+
+	season, err := engine.CloseSeason(id, s.Seasons, engine.FirstPlayed(s.Matches), s.League, at)
+	if err != nil {
+		return engine.Season{}, err
+	}
+
+	s.Seasons = append(s.Seasons, season)
+	s.League = nil
+	return season, nil
+}
+
+// Correct corrects the matches and league of the stub, keeping Scores in step.
+func (s *StubPlayerStore) Correct(_ context.Context, correction engine.Correction, by string, at time.Time) (engine.AuditEntry, error) {
+	if s.Err != nil {
+		return engine.AuditEntry{}, s.Err
+	}
+
+	entry, err := engine.Correct(correction, s.Matches, s.League, s.Seasons, by, at, len(s.Audit)+1)
+	if err != nil {
+		return engine.AuditEntry{}, err
+	}
+
+	s.Matches, s.League = entry.Apply(s.Matches, s.League)
+	for name, wins := range entry.After.Wins {
+		if s.Scores != nil {
+			s.Scores[name] = wins
+		}
+	}
+	s.Audit = append(s.Audit, entry)
+	s.Notify()
+	return entry, nil
+}
+
+func (s *StubPlayerStore) GetAudit(_ context.Context) ([]engine.AuditEntry, error) {
+	return s.Audit, s.Err
+}
+
+// StubLeagues keeps named leagues in stub stores.
+type StubLeagues struct {
+	Stores map[string]*StubPlayerStore
+	// KeyStores are the API keys of the leagues that have any.
+	KeyStores map[string]*StubKeyStore
+	// Registries are the players of the leagues that have registered any.
+	Registries map[string]*StubPlayerRegistry
+	Err        error
+}
+
+func (s *StubLeagues) CreateLeague(_ context.Context, id string) error {
+	if s.Err != nil {
+		return s.Err
+	}
+
+	if err := engine.ValidateLeagueID(id); err != nil {
+		return err
+	}
+
+	if _, ok := s.Stores[id]; ok {
+		return fmt.Errorf("%w: %s", engine.ErrLeagueExists, id)
+	}
+
+	if s.Stores == nil {
+		s.Stores = map[string]*StubPlayerStore{}
+	}
+	s.Stores[id] = &StubPlayerStore{Scores: map[string]int{}}
+	return nil
+}
+
+func (s *StubLeagues) ListLeagues(_ context.Context) ([]string, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+
+	ids := slices.Collect(maps.Keys(s.Stores))
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (s *StubLeagues) Store(_ context.Context, id string) (engine.PlayerStore, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+
+	store, ok := s.Stores[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", engine.ErrLeagueNotFound, id)
+	}
+
+	return store, nil
+}
+
+func (s *StubLeagues) Keys(_ context.Context, id string) (engine.KeyStore, error) {
+	if _, ok := s.Stores[id]; !ok {
+		return nil, fmt.Errorf("%w: %s", engine.ErrLeagueNotFound, id)
+	}
+
+	if keys, ok := s.KeyStores[id]; ok {
+		return keys, nil
+	}
+
+	return &StubKeyStore{}, nil
+}
+
+func (s *StubLeagues) Players(_ context.Context, id string) (engine.PlayerRegistry, error) {
+	if _, ok := s.Stores[id]; !ok {
+		return nil, fmt.Errorf("%w: %s", engine.ErrLeagueNotFound, id)
+	}
+
+	if s.Registries == nil {
+		s.Registries = map[string]*StubPlayerRegistry{}
+	}
+	if _, ok := s.Registries[id]; !ok {
+		s.Registries[id] = &StubPlayerRegistry{}
+	}
+
+	return s.Registries[id], nil
+}
+
+// StubPlayerRegistry keeps registered players in memory.
+type StubPlayerRegistry struct {
+	Players engine.Registry
+	Err     error
+}
+
+func (s *StubPlayerRegistry) Register(_ context.Context, name string, at time.Time) (engine.RegisteredPlayer, error) {
+	return s.change(func() (engine.Registry, engine.RegisteredPlayer, error) {
+		return s.Players.Register(name, at)
+	})
+}
+
+func (s *StubPlayerRegistry) FindPlayer(_ context.Context, name string) (engine.RegisteredPlayer, error) {
+	if s.Err != nil {
+		return engine.RegisteredPlayer{}, s.Err
+	}
+
+	player := s.Players.Find(name)
+	if player == nil {
+		return engine.RegisteredPlayer{}, fmt.Errorf("%w: %s", engine.ErrPlayerNotFound, name)
+	}
+
+	return *player, nil
+}
+
+func (s *StubPlayerRegistry) ListPlayers(_ context.Context) ([]engine.RegisteredPlayer, error) {
+	return s.Players, s.Err
+}
+
+func (s *StubPlayerRegistry) AddAlias(_ context.Context, name, alias string) (engine.RegisteredPlayer, error) {
+	return s.change(func() (engine.Registry, engine.RegisteredPlayer, error) {
+		return s.Players.AddAlias(name, alias)
+	})
+}
+
+func (s *StubPlayerRegistry) MergePlayers(_ context.Context, from, into string, at time.Time) (engine.RegisteredPlayer, error) {
+	return s.change(func() (engine.Registry, engine.RegisteredPlayer, error) {
+		return s.Players.Merge(from, into, at)
+	})
+}
+
+func (s *StubPlayerRegistry) change(change func() (engine.Registry, engine.RegisteredPlayer, error)) (engine.RegisteredPlayer, error) {
+	if s.Err != nil {
+		return engine.RegisteredPlayer{}, s.Err
+	}
+
+	players, player, err := change()
+	if err != nil {
+		return engine.RegisteredPlayer{}, err
+	}
+
+	s.Players = players
+	return player, nil
+}
+
+// StubKeyStore keeps API keys in memory.
+type StubKeyStore struct {
+	Keys []engine.APIKey
+	Err  error
+}
+
+// NewStubKeyStore keeps a key named name allowed what role is, returning its
+// secret as well.
+func NewStubKeyStore(t testing.TB, name string, role engine.Role) (*StubKeyStore, string) {
+	t.Helper()
+
+	key, secret, err := engine.NewAPIKey(name, role, time.Now())
+	AssertNoError(t, err)
+
+	return &StubKeyStore{Keys: []engine.APIKey{key}}, secret
+}
+
+func (s *StubKeyStore) AddKey(_ context.Context, key engine.APIKey) error {
+	if s.Err != nil {
+		return s.Err
+	}
+
+	s.Keys = append(s.Keys, key)
+	return nil
+}
+
+func (s *StubKeyStore) ListKeys(_ context.Context) ([]engine.APIKey, error) {
+	return s.Keys, s.Err
 }
 
-func (s *StubPlayerStore) GetLeague() engine.League {
-	return s.League
+func (s *StubKeyStore) RevokeKey(_ context.Context, id string) error {
+	if s.Err != nil {
+		return s.Err
+	}
+
+	for i, key := range s.Keys {
+		if key.ID == id {
+			s.Keys = slices.Delete(s.Keys, i, i+1)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", engine.ErrKeyNotFound, id)
+}
+
+// StubUserStore keeps users in memory.
+type StubUserStore struct {
+	Users []engine.User
+	Err   error
+}
+
+// NewStubUserStore keeps a user for every role, named after it, with the
+// password "password".
+func NewStubUserStore(t testing.TB) *StubUserStore {
+	t.Helper()
+
+	users := &StubUserStore{}
+	for _, role := range engine.Roles {
+		user, err := engine.NewUser(string(role), role, "password", time.Now())
+		AssertNoError(t, err)
+		users.Users = append(users.Users, user)
+	}
+
+	return users
+}
+
+func (s *StubUserStore) AddUser(_ context.Context, user engine.User) error {
+	if s.Err != nil {
+		return s.Err
+	}
+
+	if slices.ContainsFunc(s.Users, func(u engine.User) bool { return u.Name == user.Name }) {
+		return fmt.Errorf("%w: %s", engine.ErrUserExists, user.Name)
+	}
+
+	s.Users = append(s.Users, user)
+	return nil
+}
+
+func (s *StubUserStore) GetUser(_ context.Context, name string) (engine.User, error) {
+	if s.Err != nil {
+		return engine.User{}, s.Err
+	}
+
+	for _, user := range s.Users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+
+	return engine.User{}, fmt.Errorf("%w: %s", engine.ErrUserNotFound, name)
 }
 
-type ScheduledAlert struct {
-	At     time.Duration
-	Amount int
+func (s *StubUserStore) ListUsers(_ context.Context) ([]engine.User, error) {
+	return s.Users, s.Err
 }
 
-func (s ScheduledAlert) String() string {
-	return fmt.Sprintf("%d chips at %v", s.Amount, s.At)
+func (s *StubUserStore) RemoveUser(_ context.Context, name string) error {
+	if s.Err != nil {
+		return s.Err
+	}
+
+	i := slices.IndexFunc(s.Users, func(u engine.User) bool { return u.Name == name })
+	if i == -1 {
+		return fmt.Errorf("%w: %s", engine.ErrUserNotFound, name)
+	}
+
+	s.Users = slices.Delete(s.Users, i, i+1)
+	return nil
 }
 
+type ScheduledAlert = engine.BlindAlert
+
 type SpyBlindAlerter struct {
-	Alerts []ScheduledAlert
+	Alerts    []ScheduledAlert
+	Schedules []*SpyBlindSchedule
 }
 
-func (s *SpyBlindAlerter) ScheduleAlertAt(at time.Duration, amount int, _ io.Writer) {
-	s.Alerts = append(s.Alerts, ScheduledAlert{at, amount})
+func (s *SpyBlindAlerter) ScheduleAlerts(alerts []engine.BlindAlert, _ io.Writer) engine.BlindSchedule {
+	s.Alerts = append(s.Alerts, alerts...)
+	schedule := &SpyBlindSchedule{}
+	s.Schedules = append(s.Schedules, schedule)
+	return schedule
 }
 
+type SpyBlindSchedule struct {
+	Cancelled bool
+	Paused    bool
+}
+
+func (s *SpyBlindSchedule) Cancel() {
+	s.Cancelled = true
+}
+
+func (s *SpyBlindSchedule) Pause() {
+	s.Paused = true
+}
+
+func (s *SpyBlindSchedule) Resume() {
+	s.Paused = false
+}
+
+func (s *SpyBlindSchedule) Remaining() time.Duration {
+	return 0
+}
+
+// GameSpy records how it was played. Games are played from other goroutines,
+// so what it recorded is read through its methods.
 type GameSpy struct {
-	StartedWith int
-	StartCalled bool
-	BlindAlert  []byte
+	BlindAlert []byte
+	// Level is alerted on start to destinations taking structured alerts.
+	Level     *engine.BlindLevel
+	FinishErr error
+	Schedule  *SpyBlindSchedule
+
+	lock              sync.Mutex
+	startedWith       int
+	startedWithNames  []string
+	startedWithBlinds engine.BlindStructure
+	startCalled       bool
+	finishCalled      bool
+	finishedWith      string
+	confirmed         bool
+	stopCalled        bool
+}
 
-	FinishCalled bool
-	FinishedWith string
+// NewGame makes a factory handing out game for every game played.
+func NewGame(game engine.Game) func() engine.Game {
+	return func() engine.Game {
+		return game
+	}
 }
 
-func (g *GameSpy) Start(numberOfPlayers int, out io.Writer) {
-	g.StartCalled = true
-	g.StartedWith = numberOfPlayers
+func (g *GameSpy) Start(numberOfPlayers int, participants []string, blinds engine.BlindStructure, out io.Writer) {
+	g.lock.Lock()
+	g.startCalled = true
+	g.startedWith = numberOfPlayers
+	g.startedWithNames = participants
+	g.startedWithBlinds = blinds
+	g.lock.Unlock()
+
+	if w, ok := out.(engine.BlindWriter); ok && g.Level != nil {
+		if err := w.WriteBlind(engine.BlindAlert{Level: *g.Level}); err != nil {
+			log.Println("couldn't write an alert: ", err)
+		}
+		return
+	}
+
 	if _, err := out.Write(g.BlindAlert); err != nil {
 		log.Println("couldn't write an alert: ", err)
 	}
 }
 
-func (g *GameSpy) Finish(winner string) {
-	g.FinishedWith = winner
+func (g *GameSpy) Finish(_ context.Context, winner string, confirmed bool) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.finishCalled = true
+	g.finishedWith = winner
+	g.confirmed = confirmed
+
+	if _, err := engine.SeatedWinner(g.startedWithNames, winner); err != nil && !confirmed {
+		return err
+	}
+	return g.FinishErr
+}
+
+func (g *GameSpy) Stop() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.stopCalled = true
+}
+
+func (g *GameSpy) StartCalled() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.startCalled
+}
+
+func (g *GameSpy) StartedWith() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.startedWith
+}
+
+func (g *GameSpy) StartedWithNames() []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.startedWithNames
+}
+
+func (g *GameSpy) StartedWithBlinds() engine.BlindStructure {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.startedWithBlinds
+}
+
+func (g *GameSpy) FinishCalled() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.finishCalled
+}
+
+func (g *GameSpy) FinishedWith() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.finishedWith
+}
+
+// Confirmed is whether the win of FinishedWith was confirmed. Winners who
+// didn't play are refused unless it was.
+func (g *GameSpy) Confirmed() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.confirmed
+}
+
+func (g *GameSpy) StopCalled() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.stopCalled
+}
+
+// Blinds returns Schedule, making sure a nil one doesn't end up as a non-nil
+// interface.
+func (g *GameSpy) Blinds() engine.BlindSchedule {
+	if g.Schedule == nil {
+		return nil
+	}
+
+	return g.Schedule
 }
 
 func AssertPlayerWin(t testing.TB, store *StubPlayerStore, winner string) {
@@ -169,3 +626,38 @@ func AssertContentType(t testing.TB, response *httptest.ResponseRecorder, want s
 	}
 
 }
+
+func AssertRecordWin(t testing.TB, store engine.PlayerStore, name string) {
+	t.Helper()
+
+	if err := store.RecordWin(context.Background(), name); err != nil {
+		t.Errorf("couldn't record a win for %s: %v", name, err)
+	}
+}
+
+func AssertPlayerScore(t testing.TB, store engine.PlayerStore, name string, want int) {
+	t.Helper()
+
+	got, err := store.GetPlayerScore(context.Background(), name)
+	AssertNoError(t, err)
+	AssertScoreEquals(t, got, want)
+}
+
+func AssertStoreLeague(t testing.TB, store engine.PlayerStore, want engine.League) {
+	t.Helper()
+
+	got, err := store.GetLeague(context.Background())
+	AssertNoError(t, err)
+	AssertLeague(t, got, want)
+}
+
+func AssertMatches(t testing.TB, store engine.PlayerStore, want []engine.Match) {
+	t.Helper()
+
+	got, err := store.GetMatches(context.Background())
+	AssertNoError(t, err)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got matches %+v, want %+v", got, want)
+	}
+}